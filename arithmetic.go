@@ -0,0 +1,264 @@
+package vcalc
+
+// The coordinates of one coordinate system written in the coordinates of another, keyed by "from>to"
+var conversions = map[string]map[string]string{
+	"cyl>car":    {"r": "sqrt(x^2+y^2)", "phi": "atan2(y, x)", "z": "z"},
+	"sph>car":    {"r": "sqrt(x^2+y^2+z^2)", "theta": "atan2(sqrt(x^2+y^2), z)", "phi": "atan2(y, x)"},
+	"car>cyl":    {"x": "r*cos(phi)", "y": "r*sin(phi)", "z": "z"},
+	"sph>cyl":    {"r": "sqrt(r^2+z^2)", "theta": "atan2(r, z)", "phi": "phi"},
+	"car>sph":    {"x": "r*sin(theta)*cos(phi)", "y": "r*sin(theta)*sin(phi)", "z": "r*cos(theta)"},
+	"cyl>sph":    {"r": "r*sin(theta)", "phi": "phi", "z": "r*cos(theta)"},
+	"polar>car2": {"r": "sqrt(x^2+y^2)", "phi": "atan2(y, x)"},
+	"car2>polar": {"x": "r*cos(phi)", "y": "r*sin(phi)"},
+}
+
+// The cartesian components of the unit vectors of each coordinate system written in its coordinates, like basis
+var unitVectors = map[string][][]string{
+	"car": {{"1", "0", "0"}, {"0", "1", "0"}, {"0", "0", "1"}},
+	"cyl": {
+		{"cos(phi)", "sin(phi)", "0"},
+		{"-sin(phi)", "cos(phi)", "0"},
+		{"0", "0", "1"}},
+	"sph": {
+		{"sin(theta)*cos(phi)", "sin(theta)*sin(phi)", "cos(theta)"},
+		{"cos(theta)*cos(phi)", "cos(theta)*sin(phi)", "-sin(theta)"},
+		{"-sin(phi)", "cos(phi)", "0"}},
+	"car2": {{"1", "0"}, {"0", "1"}},
+	"polar": {
+		{"cos(phi)", "sin(phi)"},
+		{"-sin(phi)", "cos(phi)"}},
+}
+
+// Returns the trees of the expressions of a field in from with the settings o written in the coordinates of to
+// If vector is true the expressions are the components of a vector field, which are also moved to the unit vectors of to
+// and simplified, since the products of the unit vectors are otherwise hard to read
+// Panics if the coordinate systems do not have the same number of coordinates
+func (o fieldOptions) convert(from, to string, vector bool, expressions ...string) []*node {
+	res := make([]*node, len(expressions))
+	for i, e := range expressions {
+		res[i] = o.mustParse(e)
+	}
+	if from == to {
+		return res
+	}
+	exprs, ok := conversions[from+">"+to]
+	if !ok {
+		panic("Can not combine a field in " + from + " with a field in " + to + ", the coordinate systems must have the same number of coordinates")
+	}
+	values := make(map[string]*node, len(exprs))
+	for name, e := range exprs {
+		values[name] = mustParse(e)
+	}
+	for i := range res {
+		res[i] = res[i].substitute(values)
+	}
+	if !vector {
+		return res
+	}
+	// The component along a unit vector of to is the sum of each component times the product of its unit vector
+	// with the unit vector of to, where the unit vectors of from are also written in the coordinates of to
+	ef, et := unitVectors[from], unitVectors[to]
+	components := make([]*node, len(res))
+	for i := range et {
+		components[i] = num(0)
+		for j := range ef {
+			dot := num(0)
+			for k := range et[i] {
+				dot = op("+", dot, op("*", mustParse(et[i][k]), mustParse(ef[j][k]).substitute(values)))
+			}
+			components[i] = op("+", components[i], op("*", dot.simplify(), res[j]))
+		}
+		components[i] = components[i].simplify()
+	}
+	return components
+}
+
+// Returns an option setting every setting of a field to those in opts
+func withOptions(opts fieldOptions) FieldOption {
+	return func(o *fieldOptions) {
+		*o = opts
+	}
+}
+
+// Returns the merged settings of two fields and the trees of the expressions a of the first field with the
+// settings o in coordsys and b of the second field with the settings p in from, written in coordsys
+func operands(o fieldOptions, coordsys string, a []string, p fieldOptions, from string, vector bool, b []string) (fieldOptions, []*node, []*node) {
+	return o.merge(p), o.convert(coordsys, coordsys, vector, a...), p.convert(from, coordsys, vector, b...)
+}
+
+// Returns the sum of the trees a and b, or their difference if sub is true, component by component
+func addNodes(a, b []*node, sub bool) []string {
+	res := make([]string, len(a))
+	for i := range a {
+		if sub {
+			res[i] = op("-", a[i], b[i]).String()
+		} else {
+			res[i] = op("+", a[i], b[i]).String()
+		}
+	}
+	return res
+}
+
+// Returns each tree of a times the number or tree f
+func scaleNodes(f *node, a []*node) []string {
+	res := make([]string, len(a))
+	for i := range a {
+		res[i] = op("*", f, a[i]).String()
+	}
+	return res
+}
+
+// Returns the dot product of the components a and b in the same orthonormal unit vectors
+func dotNodes(a, b []*node) string {
+	res := num(0)
+	for i := range a {
+		res = op("+", res, op("*", a[i], b[i]))
+	}
+	return res.String()
+}
+
+// Returns the component of the cross product of a and b along the unit vector k, where i, j and k are
+// the indices of a right-handed system of unit vectors
+func crossNode(a, b []*node, i, j int) string {
+	return op("-", op("*", a[i], b[j]), op("*", a[j], b[i])).String()
+}
+
+// Returns the sum of scalarField and g as a new scalar field in the coordinate system of scalarField
+// If g is in another coordinate system its expression is written in the coordinates of scalarField
+// The new field has the parameters and custom functions of both fields
+func (s scalarField) Add(g scalarField) scalarField {
+	opts, a, b := operands(s.opts, s.coordsys, []string{s.expression}, g.opts, g.coordsys, false, []string{g.expression})
+	return NewScalarField(addNodes(a, b, false)[0], s.coordsys, withOptions(opts))
+}
+
+// Returns scalarField minus g as a new scalar field, like Add
+func (s scalarField) Sub(g scalarField) scalarField {
+	opts, a, b := operands(s.opts, s.coordsys, []string{s.expression}, g.opts, g.coordsys, false, []string{g.expression})
+	return NewScalarField(addNodes(a, b, true)[0], s.coordsys, withOptions(opts))
+}
+
+// Returns the product of scalarField and g as a new scalar field, like Add
+func (s scalarField) Mul(g scalarField) scalarField {
+	opts, a, b := operands(s.opts, s.coordsys, []string{s.expression}, g.opts, g.coordsys, false, []string{g.expression})
+	return NewScalarField(scaleNodes(a[0], b)[0], s.coordsys, withOptions(opts))
+}
+
+// Returns scalarField times the number a as a new scalar field
+func (s scalarField) Scale(a float64) scalarField {
+	return s.withExpression(op("*", num(a), s.tree).String())
+}
+
+// Returns the sum of vectorField and G as a new vector field in the coordinate system of vectorField
+// If G is in another coordinate system its components are written in the coordinates and unit vectors of vectorField
+// The new field has the parameters and custom functions of both fields
+func (v vectorField) Add(G vectorField) vectorField {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	e := addNodes(a, b, false)
+	return NewVectorField(e[0], e[1], e[2], v.coordsys, withOptions(opts))
+}
+
+// Returns vectorField minus G as a new vector field, like Add
+func (v vectorField) Sub(G vectorField) vectorField {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	e := addNodes(a, b, true)
+	return NewVectorField(e[0], e[1], e[2], v.coordsys, withOptions(opts))
+}
+
+// Returns vectorField times the number a as a new vector field
+func (v vectorField) Scale(a float64) vectorField {
+	e := scaleNodes(num(a), v.opts.convert(v.coordsys, v.coordsys, true, v.expressions()...))
+	return v.withExpressions(e[0], e[1], e[2])
+}
+
+// Returns the scalar field f times vectorField as a new vector field in the coordinate system of vectorField, like Add
+func (v vectorField) Mul(f scalarField) vectorField {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), f.opts, f.coordsys, false, []string{f.expression})
+	e := scaleNodes(b[0], a)
+	return NewVectorField(e[0], e[1], e[2], v.coordsys, withOptions(opts))
+}
+
+// Returns the dot product of vectorField and G as a new scalar field in the coordinate system of vectorField, like Add
+func (v vectorField) Dot(G vectorField) scalarField {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	return NewScalarField(dotNodes(a, b), v.coordsys, withOptions(opts))
+}
+
+// Returns the cross product of vectorField and G as a new vector field in the coordinate system of vectorField, like Add
+func (v vectorField) Cross(G vectorField) vectorField {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	return NewVectorField(crossNode(a, b, 1, 2), crossNode(a, b, 2, 0), crossNode(a, b, 0, 1), v.coordsys, withOptions(opts))
+}
+
+// Returns the expressions of the components of vectorField
+func (v vectorField) expressions() []string {
+	return []string{v.expressionCoord1, v.expressionCoord2, v.expressionCoord3}
+}
+
+// Returns the sum of scalarField2D and g as a new two-dimensional scalar field, like Add of scalarField
+func (s scalarField2D) Add(g scalarField2D) scalarField2D {
+	opts, a, b := operands(s.opts, s.coordsys, []string{s.expression}, g.opts, g.coordsys, false, []string{g.expression})
+	return NewScalarField2D(addNodes(a, b, false)[0], s.coordsys, withOptions(opts))
+}
+
+// Returns scalarField2D minus g as a new two-dimensional scalar field, like Add of scalarField
+func (s scalarField2D) Sub(g scalarField2D) scalarField2D {
+	opts, a, b := operands(s.opts, s.coordsys, []string{s.expression}, g.opts, g.coordsys, false, []string{g.expression})
+	return NewScalarField2D(addNodes(a, b, true)[0], s.coordsys, withOptions(opts))
+}
+
+// Returns the product of scalarField2D and g as a new two-dimensional scalar field, like Add of scalarField
+func (s scalarField2D) Mul(g scalarField2D) scalarField2D {
+	opts, a, b := operands(s.opts, s.coordsys, []string{s.expression}, g.opts, g.coordsys, false, []string{g.expression})
+	return NewScalarField2D(scaleNodes(a[0], b)[0], s.coordsys, withOptions(opts))
+}
+
+// Returns scalarField2D times the number a as a new two-dimensional scalar field
+func (s scalarField2D) Scale(a float64) scalarField2D {
+	return s.withExpression(op("*", num(a), s.tree).String())
+}
+
+// Returns the sum of vectorField2D and G as a new two-dimensional vector field, like Add of vectorField
+func (v vectorField2D) Add(G vectorField2D) vectorField2D {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	e := addNodes(a, b, false)
+	return NewVectorField2D(e[0], e[1], v.coordsys, withOptions(opts))
+}
+
+// Returns vectorField2D minus G as a new two-dimensional vector field, like Add of vectorField
+func (v vectorField2D) Sub(G vectorField2D) vectorField2D {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	e := addNodes(a, b, true)
+	return NewVectorField2D(e[0], e[1], v.coordsys, withOptions(opts))
+}
+
+// Returns vectorField2D times the number a as a new two-dimensional vector field
+func (v vectorField2D) Scale(a float64) vectorField2D {
+	e := scaleNodes(num(a), v.opts.convert(v.coordsys, v.coordsys, true, v.expressions()...))
+	return v.withExpressions(e[0], e[1])
+}
+
+// Returns the two-dimensional scalar field f times vectorField2D as a new two-dimensional vector field,
+// like Add of vectorField
+func (v vectorField2D) Mul(f scalarField2D) vectorField2D {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), f.opts, f.coordsys, false, []string{f.expression})
+	e := scaleNodes(b[0], a)
+	return NewVectorField2D(e[0], e[1], v.coordsys, withOptions(opts))
+}
+
+// Returns the dot product of vectorField2D and G as a new two-dimensional scalar field, like Add of vectorField
+func (v vectorField2D) Dot(G vectorField2D) scalarField2D {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	return NewScalarField2D(dotNodes(a, b), v.coordsys, withOptions(opts))
+}
+
+// Returns the cross product of vectorField2D and G, which is perpendicular to the plane,
+// as a new two-dimensional scalar field of its component along the z axis, like Add of vectorField
+func (v vectorField2D) Cross(G vectorField2D) scalarField2D {
+	opts, a, b := operands(v.opts, v.coordsys, v.expressions(), G.opts, G.coordsys, true, G.expressions())
+	return NewScalarField2D(crossNode(a, b, 0, 1), v.coordsys, withOptions(opts))
+}
+
+// Returns the expressions of the components of vectorField2D
+func (v vectorField2D) expressions() []string {
+	return []string{v.expressionCoord1, v.expressionCoord2}
+}
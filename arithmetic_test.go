@@ -0,0 +1,132 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestArithmetic(t *testing.T) {
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{NewScalarField("x^2", "car").Add(NewScalarField("y", "car")).String(), "x^2+y"},
+		{NewScalarField("x", "car").Sub(NewScalarField("y+z", "car")).String(), "x-(y+z)"},
+		{NewScalarField("x+1", "car").Mul(NewScalarField("y", "car")).String(), "(x+1)*y"},
+		{NewScalarField("r*z", "cyl").Scale(-2).String(), "-2*(r*z)"},
+		{NewScalarField("r", "cyl").Add(NewScalarField("x*z", "car")).String(), "r+r*cos(phi)*z"},
+		{NewScalarField("z", "car").Mul(NewScalarField("r", "sph")).String(), "z*sqrt(x^2+y^2+z^2)"},
+		{NewVectorField("x", "y", "0", "car").Add(NewVectorField("1", "0", "z", "car")).String(), "(x+1, y, z)"},
+		{NewVectorField("x", "y", "z", "car").Sub(NewVectorField("x", "0", "0", "car")).String(), "(x-x, y, z)"},
+		{NewVectorField("r", "0", "z", "cyl").Scale(0.5).String(), "(0.5*r, 0, 0.5*z)"},
+		{NewVectorField("x", "0", "0", "car").Mul(NewScalarField("y", "car")).String(), "(y*x, 0, 0)"},
+		{NewVectorField("x", "y", "z", "car").Dot(NewVectorField("1", "2", "3", "car")).String(), "x+y*2+z*3"},
+		{NewVectorField("1", "0", "0", "car").Cross(NewVectorField("0", "1", "0", "car")).String(), "(0, 0, 1)"},
+		{NewVectorField("r", "0", "0", "cyl").Cross(NewVectorField("0", "1", "0", "cyl")).String(), "(0, 0, r)"},
+		{NewVectorField("1", "0", "0", "cyl").Add(NewVectorField("1", "0", "0", "car")).String(), "(1+cos(phi), -sin(phi), 0)"},
+		{NewVectorField("0", "0", "0", "sph").Add(NewVectorField("0", "0", "1", "cyl")).String(), "(cos(theta), -sin(theta), 0)"},
+		{NewVectorField("1", "0", "0", "sph").Add(NewVectorField("x", "y", "0", "car")).String(), "(1+sin(theta)^2*r, cos(theta)*r*sin(theta), 0)"},
+		{NewScalarField2D("x", "car2").Add(NewScalarField2D("r", "polar")).String(), "x+sqrt(x^2+y^2)"},
+		{NewScalarField2D("x", "car2").Scale(3).String(), "3*x"},
+		{NewScalarField2D("x", "car2").Sub(NewScalarField2D("y", "car2")).Mul(NewScalarField2D("y", "car2")).String(), "(x-y)*y"},
+		{NewVectorField2D("r", "0", "polar").Add(NewVectorField2D("x", "y", "car2")).String(), "(r+r, 0)"},
+		{NewVectorField2D("x", "y", "car2").Sub(NewVectorField2D("y", "x", "car2")).Scale(2).String(), "(2*(x-y), 2*(y-x))"},
+		{NewVectorField2D("1", "0", "car2").Mul(NewScalarField2D("x", "car2")).String(), "(x, 0)"},
+		{NewVectorField2D("x", "y", "car2").Dot(NewVectorField2D("y", "x", "car2")).String(), "x*y+y*x"},
+		{NewVectorField2D("1", "0", "car2").Cross(NewVectorField2D("0", "x", "car2")).String(), "x"},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestArithmeticConversion(t *testing.T) {
+	// A sum of fields in different coordinate systems has the sum of their values at the same point
+	f, g := NewScalarField("x*y+z", "car"), NewScalarField("r^2*cos(theta)", "sph")
+	F, G := NewVectorField("x", "y*z", "1", "car"), NewVectorField("r", "sin(theta)", "r*cos(phi)", "sph")
+	H := NewVectorField("z", "r*phi", "r", "cyl")
+	for _, c := range [][]float64{{1, 0.5, 2}, {-2, 1, 0.3}, {0.3, -3, -1}} {
+		s := fromCartesian(c, "sph")
+		if exp, got := f.eval(c)+g.eval(s), f.Add(g).eval(c); math.Abs(exp-got) > 1e-9 {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+		sum := vecToCartesian(G.eval(s), s, "sph")
+		exp := []float64{F.eval(c)[0] + sum[0], F.eval(c)[1] + sum[1], F.eval(c)[2] + sum[2]}
+		if got := F.Add(G).eval(c); !almostEqual(exp, got, 1e-9) {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+		// The same in spherical unit vectors
+		y := fromCartesian(c, "cyl")
+		h := vecFromCartesian(vecToCartesian(H.eval(y), y, "cyl"), s, "sph")
+		exp = []float64{G.eval(s)[0] + h[0], G.eval(s)[1] + h[1], G.eval(s)[2] + h[2]}
+		if got := G.Add(H).eval(s); !almostEqual(exp, got, 1e-9) {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestArithmeticIdentities(t *testing.T) {
+	// The product rules div(fF) = f div F + grad f · F and rot(fF) = f rot F + grad f × F
+	f := NewScalarField("r^2*sin(theta)", "sph")
+	F := NewVectorField("r*cos(theta)", "sin(phi)", "r", "sph")
+	for _, c := range [][]float64{{1, 0.5, 2}, {2, 1, 0.3}, {0.7, 2, -1}} {
+		grad := f.Grad(c)
+		exp := f.eval(c)*F.Div(c) + grad[0]*F.eval(c)[0] + grad[1]*F.eval(c)[1] + grad[2]*F.eval(c)[2]
+		if got := F.Mul(f).Div(c); math.Abs(exp-got) > 1e-4 {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+	g := NewScalarField("r^2*cos(phi)+z", "cyl")
+	H := NewVectorField("z", "r*sin(phi)", "r^2", "cyl")
+	for _, c := range [][]float64{{1, 0.5, 2}, {2, 1, 0.3}, {0.7, 2, -1}} {
+		rot, grad, v := H.Rot(c), g.Grad(c), H.eval(c)
+		cross := []float64{grad[1]*v[2] - grad[2]*v[1], grad[2]*v[0] - grad[0]*v[2], grad[0]*v[1] - grad[1]*v[0]}
+		exp := []float64{g.eval(c)*rot[0] + cross[0], g.eval(c)*rot[1] + cross[1], g.eval(c)*rot[2] + cross[2]}
+		if got := H.Mul(g).Rot(c); !almostEqual(exp, got, 1e-4) {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+	// F × F is zero and F · (F × G) is zero
+	G := NewVectorField("x", "z^2", "y", "car")
+	if got := F.Cross(F).Simplify().String(); got != "(0, 0, 0)" {
+		t.Error("Test failed: expected {", "(0, 0, 0)", "} and got {", got, "}")
+	}
+	for _, c := range [][]float64{{1, 0.5, 2}, {2, 1, 0.3}} {
+		if got := F.Dot(F.Cross(G)).eval(c); math.Abs(got) > 1e-9 {
+			t.Error("Test failed: {", c, " } inputted, expected {", 0, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestArithmeticOptions(t *testing.T) {
+	// The parameters, bound values and functions of both fields are kept
+	r := testRegistry()
+	f := NewScalarField("a*x", "car", WithParams("a")).Bind("a", 2)
+	g := NewScalarField("b*window(y)", "car", WithParams("a", "b"), WithFuncs(r)).Bind("a", 2).Bind("b", 3)
+	if exp, got := 2+3*math.Exp(-4), f.Add(g).eval([]float64{1, 2, 0}); got != exp {
+		t.Error("Test failed: expected {", exp, "} and got {", got, "}")
+	}
+	if got := g.Sub(f).Diff("b").String(); got != "window(y)" {
+		t.Error("Test failed: expected {", "window(y)", "} and got {", got, "}")
+	}
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"different values", func() { f.Add(NewScalarField("a", "car", WithParams("a")).Bind("a", 3)) }},
+		{"different functions", func() { g.Mul(NewScalarField("window(x)", "car", WithFuncs(testRegistry()))) }},
+		{"other dimension", func() { NewScalarField("x", "car").Add(NewScalarField("x", "car2")) }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
@@ -0,0 +1,328 @@
+package vcalc
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Which part of a complex field is calculated by fnN, a real field is notComplex
+type complexPart int
+
+const (
+	notComplex complexPart = iota
+	realPart
+	imagPart
+)
+
+// The name of the imaginary unit, which can be used in the expressions of complex fields
+const imagName = "i"
+
+// The functions which are calculated with complex arguments in complex fields
+var complexFunctions = map[string]func(args ...complex128) complex128{
+	"sin":   func(args ...complex128) complex128 { return cmplx.Sin(args[0]) },
+	"cos":   func(args ...complex128) complex128 { return cmplx.Cos(args[0]) },
+	"tan":   func(args ...complex128) complex128 { return cmplx.Tan(args[0]) },
+	"exp":   func(args ...complex128) complex128 { return cmplx.Exp(args[0]) },
+	"sqrt":  func(args ...complex128) complex128 { return cmplx.Sqrt(args[0]) },
+	"log":   func(args ...complex128) complex128 { return cmplx.Log(args[0]) },
+	"ln":    func(args ...complex128) complex128 { return cmplx.Log(args[0]) },
+	"log10": func(args ...complex128) complex128 { return cmplx.Log10(args[0]) },
+	"abs":   func(args ...complex128) complex128 { return complex(cmplx.Abs(args[0]), 0) },
+	"asin":  func(args ...complex128) complex128 { return cmplx.Asin(args[0]) },
+	"acos":  func(args ...complex128) complex128 { return cmplx.Acos(args[0]) },
+	"atan":  func(args ...complex128) complex128 { return cmplx.Atan(args[0]) },
+	"sinh":  func(args ...complex128) complex128 { return cmplx.Sinh(args[0]) },
+	"cosh":  func(args ...complex128) complex128 { return cmplx.Cosh(args[0]) },
+	"tanh":  func(args ...complex128) complex128 { return cmplx.Tanh(args[0]) },
+	"asinh": func(args ...complex128) complex128 { return cmplx.Asinh(args[0]) },
+	"acosh": func(args ...complex128) complex128 { return cmplx.Acosh(args[0]) },
+	"atanh": func(args ...complex128) complex128 { return cmplx.Atanh(args[0]) },
+	"pow":   func(args ...complex128) complex128 { return complexPow(args[0], args[1]) },
+}
+
+// Returns a to the power b, exactly for real numbers where the real power is defined and by repeated
+// multiplication for integer exponents, so that i^2 is -1 without rounding errors
+func complexPow(a, b complex128) complex128 {
+	if imag(b) != 0 {
+		return cmplx.Pow(a, b)
+	}
+	if imag(a) == 0 {
+		if v := math.Pow(real(a), real(b)); !math.IsNaN(v) || math.IsNaN(real(a)) || math.IsNaN(real(b)) {
+			return complex(v, 0)
+		}
+	}
+	n := real(b)
+	if n != math.Trunc(n) || math.Abs(n) > 64 {
+		return cmplx.Pow(a, b)
+	}
+	res := complex(1, 0)
+	for k := 0; k < int(math.Abs(n)); k++ {
+		res *= a
+	}
+	if n < 0 {
+		return 1 / res
+	}
+	return res
+}
+
+// Returns the complex calculation of the tree n at point c, where the name i is the imaginary unit
+// A comparison or function without a complex version is calculated from the real arguments, and is NaN if an
+// argument is not real
+func (n *node) evalComplex(c []float64, coords []string) complex128 {
+	switch n.kind {
+	case numNode:
+		return complex(n.value, 0)
+	case varNode:
+		if n.name == imagName {
+			return 1i
+		}
+		return complex(n.eval(c, coords), 0)
+	case negNode:
+		return -n.args[0].evalComplex(c, coords)
+	case opNode:
+		a, b := n.args[0].evalComplex(c, coords), n.args[1].evalComplex(c, coords)
+		switch n.op {
+		case "+":
+			return a + b
+		case "-":
+			return a - b
+		case "*":
+			return a * b
+		case "/":
+			return a / b
+		case "^":
+			return complexPow(a, b)
+		default:
+			if imag(a) != 0 || imag(b) != 0 {
+				return cmplx.NaN()
+			}
+			return complex(getOPR(n.op, real(a), real(b)), 0)
+		}
+	default:
+		args := make([]complex128, len(n.args))
+		for i, arg := range n.args {
+			args[i] = arg.evalComplex(c, coords)
+		}
+		if f, ok := complexFunctions[n.name]; ok && n.fn == nil {
+			return f(args...)
+		}
+		if f, ok := functions[n.name]; ok && f.piecewise {
+			// The value after the first condition that is not 0, like choose
+			for i := 0; i+1 < len(args); i += 2 {
+				if args[i] != 0 {
+					return args[i+1]
+				}
+			}
+			return args[len(args)-1]
+		}
+		reals := make([]float64, len(args))
+		for i, arg := range args {
+			if imag(arg) != 0 {
+				return cmplx.NaN()
+			}
+			reals[i] = real(arg)
+		}
+		if n.fn != nil {
+			return complex(n.fn.eval(reals...), 0)
+		}
+		return complex(getFUNC(n.name, reals...), 0)
+	}
+}
+
+// Returns the complex numbers with the real parts re and the imaginary parts im
+func complexes(re, im []float64) []complex128 {
+	res := make([]complex128, len(re))
+	for i := range re {
+		res[i] = complex(re[i], im[i])
+	}
+	return res
+}
+
+// A complex scalar field has a mathematical expression with complex values, where i is the imaginary unit,
+// and a coordinate system defined as "car" for cartesian, "cyl" for cylinder, "sph" for spherical
+type complexScalarField struct {
+	expression string
+	tree       *node
+	coordsys   string
+	opts       fieldOptions
+}
+
+// A complex vector field has a mathematical expression with complex values for each coordinate in 3-dimensional
+// space, where i is the imaginary unit, and a coordinate system like a complex scalar field
+type complexVectorField struct {
+	expressionCoord1 string
+	expressionCoord2 string
+	expressionCoord3 string
+	treeCoord1       *node
+	treeCoord2       *node
+	treeCoord3       *node
+	coordsys         string
+	opts             fieldOptions
+}
+
+// Returns a new complex scalar field, the options opts change the default settings of the field
+func NewComplexScalarField(expression string, coordsys string, opts ...FieldOption) complexScalarField {
+	s := complexScalarField{}
+	s.expression = expression
+	s.coordsys = coordsys
+	s.opts = newFieldOptions(opts)
+	s.opts.part = realPart
+	checkCoords(expression, coordsys, s.opts)
+	s.tree = s.opts.mustParse(expression)
+	return s
+}
+
+// Returns a new complex vector field, the options opts change the default settings of the field
+func NewComplexVectorField(e1, e2, e3, coordsys string, opts ...FieldOption) complexVectorField {
+	v := complexVectorField{}
+	v.expressionCoord1 = e1
+	v.expressionCoord2 = e2
+	v.expressionCoord3 = e3
+	v.coordsys = coordsys
+	v.opts = newFieldOptions(opts)
+	v.opts.part = realPart
+	for _, e := range []string{e1, e2, e3} {
+		checkCoords(e, coordsys, v.opts)
+	}
+	v.treeCoord1, v.treeCoord2, v.treeCoord3 = v.opts.mustParse(e1), v.opts.mustParse(e2), v.opts.mustParse(e3)
+	return v
+}
+
+// Returns the real or imaginary part of complexScalarField, chosen by part, as a real scalar field
+func (s complexScalarField) part(part complexPart) scalarField {
+	opts := s.opts
+	opts.part = part
+	return scalarField{expression: s.expression, tree: s.tree, coordsys: s.coordsys, opts: opts}
+}
+
+// Returns the real or imaginary part of complexVectorField, chosen by part, as a real vector field
+func (v complexVectorField) part(part complexPart) vectorField {
+	opts := v.opts
+	opts.part = part
+	return vectorField{expressionCoord1: v.expressionCoord1, expressionCoord2: v.expressionCoord2,
+		expressionCoord3: v.expressionCoord3, treeCoord1: v.treeCoord1, treeCoord2: v.treeCoord2,
+		treeCoord3: v.treeCoord3, coordsys: v.coordsys, opts: opts}
+}
+
+// Returns the calculation of complexScalarField at point c with its parameters bound
+func (s complexScalarField) eval(c []float64) complex128 {
+	c, coords := s.opts.withTime(c, coordNames(s.coordsys))
+	return s.tree.evalComplex(c, coords)
+}
+
+// Returns the calculation of each coordinate of complexVectorField at point c with its parameters bound
+func (v complexVectorField) eval(c []float64) []complex128 {
+	return complexes(v.part(realPart).eval(c), v.part(imagPart).eval(c))
+}
+
+// Returns a copy of complexScalarField where the parameter name has the value value
+func (s complexScalarField) Bind(name string, value float64) complexScalarField {
+	return s.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of complexScalarField where each parameter in values has its value
+func (s complexScalarField) BindParams(values map[string]float64) complexScalarField {
+	s.opts = s.opts.bind(values)
+	return s
+}
+
+// Returns a copy of complexScalarField at the time t, where it is evaluated and its operators are calculated
+func (s complexScalarField) At(t float64) complexScalarField {
+	s.opts.time = t
+	return s
+}
+
+// Returns the expression of complexScalarField as canonical text
+func (s complexScalarField) String() string {
+	return s.tree.String()
+}
+
+// Returns a copy of complexVectorField where the parameter name has the value value
+func (v complexVectorField) Bind(name string, value float64) complexVectorField {
+	return v.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of complexVectorField where each parameter in values has its value
+func (v complexVectorField) BindParams(values map[string]float64) complexVectorField {
+	v.opts = v.opts.bind(values)
+	return v
+}
+
+// Returns a copy of complexVectorField at the time t, where it is evaluated and its operators are calculated
+func (v complexVectorField) At(t float64) complexVectorField {
+	v.opts.time = t
+	return v
+}
+
+// Returns the components of complexVectorField as canonical text like (exp(i*z), 0, 0)
+func (v complexVectorField) String() string {
+	return v.opts.vectorString(v.expressionCoord1, v.expressionCoord2, v.expressionCoord3)
+}
+
+// Calculates the gradient of complexScalarField, the gradient of its real part plus i times that of its imaginary part
+// Returns a slice of complex128 containg the calculated gradient at point c
+func (s complexScalarField) Grad(c []float64) []complex128 {
+	return complexes(s.part(realPart).Grad(c), s.part(imagPart).Grad(c))
+}
+
+// Calculates the gradient of complexScalarField like Grad
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (s complexScalarField) TryGrad(c []float64) ([]complex128, error) {
+	re, err := s.part(realPart).TryGrad(c)
+	if err != nil {
+		return nil, err
+	}
+	im, _ := s.part(imagPart).TryGrad(c)
+	return complexes(re, im), nil
+}
+
+// Calculates the laplacian of complexScalarField
+// Returns a complex128 containg the calculated laplacian at point c
+func (s complexScalarField) Laplacian(c []float64) complex128 {
+	return complex(s.part(realPart).Laplacian(c), s.part(imagPart).Laplacian(c))
+}
+
+// Calculates the laplacian of complexScalarField like Laplacian
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (s complexScalarField) TryLaplacian(c []float64) (complex128, error) {
+	re, err := s.part(realPart).TryLaplacian(c)
+	if err != nil {
+		return 0, err
+	}
+	im, _ := s.part(imagPart).TryLaplacian(c)
+	return complex(re, im), nil
+}
+
+// Calculates the divergence of complexVectorField
+// Returns a complex128 containg the calculated divergence at point c
+func (v complexVectorField) Div(c []float64) complex128 {
+	return complex(v.part(realPart).Div(c), v.part(imagPart).Div(c))
+}
+
+// Calculates the divergence of complexVectorField like Div
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (v complexVectorField) TryDiv(c []float64) (complex128, error) {
+	re, err := v.part(realPart).TryDiv(c)
+	if err != nil {
+		return 0, err
+	}
+	im, _ := v.part(imagPart).TryDiv(c)
+	return complex(re, im), nil
+}
+
+// Calculates the rotation of complexVectorField
+// Returns a slice of complex128 containg the calculated rotation at point c
+func (v complexVectorField) Rot(c []float64) []complex128 {
+	return complexes(v.part(realPart).Rot(c), v.part(imagPart).Rot(c))
+}
+
+// Calculates the rotation of complexVectorField like Rot
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (v complexVectorField) TryRot(c []float64) ([]complex128, error) {
+	re, err := v.part(realPart).TryRot(c)
+	if err != nil {
+		return nil, err
+	}
+	im, _ := v.part(imagPart).TryRot(c)
+	return complexes(re, im), nil
+}
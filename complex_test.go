@@ -0,0 +1,136 @@
+package vcalc
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// Returns true if the complex numbers a and b differ by at most tol in each element, or are both NaN
+func complexEqual(a, b []complex128, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if cmplx.IsNaN(a[i]) && cmplx.IsNaN(b[i]) {
+			continue
+		}
+		if cmplx.Abs(a[i]-b[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEvalComplex(t *testing.T) {
+	var tests = []struct {
+		expression string
+		c          []float64
+		exp        complex128
+	}{
+		{"exp(i*z)", []float64{0, 0, math.Pi / 2}, 1i},
+		{"i^2", []float64{0, 0, 0}, -1},
+		{"i^-1", []float64{0, 0, 0}, -1i},
+		{"sqrt(-4)", []float64{0, 0, 0}, 2i},
+		{"(1+i)*(1-i)", []float64{0, 0, 0}, 2},
+		{"abs(3+4i)", []float64{0, 0, 0}, 5},
+		{"2i x", []float64{3, 0, 0}, 6i},
+		{"x^2", []float64{-2, 0, 0}, 4},
+		{"(-1)^0.5", []float64{0, 0, 0}, cmplx.Pow(-1, 0.5)},
+		{"if(x<1, i, 1)", []float64{0, 0, 0}, 1i},
+		{"piecewise(x<0, 1, x<1, i*y, 0)", []float64{0.5, 2, 0}, 2i},
+		{"besselj(0, x)", []float64{0, 0, 0}, 1},
+		{"besselj(0, i)", []float64{0, 0, 0}, cmplx.NaN()},
+		{"x < i", []float64{0, 0, 0}, cmplx.NaN()},
+		{"cos(i*x)", []float64{1, 0, 0}, complex(math.Cosh(1), 0)},
+	}
+	for _, v := range tests {
+		if got := NewComplexScalarField(v.expression, "car").eval(v.c); !complexEqual([]complex128{got}, []complex128{v.exp}, 1e-12) {
+			t.Error("Test failed: {", v.expression, v.c, " } inputted, expected {", v.exp, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestComplexOperators(t *testing.T) {
+	k := 2.0
+	// A plane wave along z and a spherical wave solve the Helmholtz equation laplacian(u) = -k^2 u
+	plane := NewComplexScalarField("exp(i*k*z)", "car", WithParams("k")).Bind("k", k)
+	spherical := NewComplexScalarField("exp(i*k*r)/r", "sph", WithParams("k")).Bind("k", k)
+	cylinder := NewComplexScalarField("exp(i*k*z)*r^2", "cyl", WithParams("k")).Bind("k", k)
+	for _, c := range [][]float64{{1, 0.5, 2}, {2, 1, 0.3}, {0.7, 2, -1}} {
+		if got, exp := plane.Grad(c), []complex128{0, 0, 1i * complex(k, 0) * plane.eval(c)}; !complexEqual(got, exp, 1e-6) {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+		for _, s := range []complexScalarField{plane, spherical} {
+			if got, exp := s.Laplacian(c), complex(-k*k, 0)*s.eval(c); cmplx.Abs(got-exp) > 1e-4 {
+				t.Error("Test failed: {", s, c, " } inputted, expected {", exp, "} and got {", got, "}")
+			}
+		}
+		// laplacian(r^2 f(z)) = 4 f(z) + r^2 f''(z) in cylinder coordinates
+		f := cmplx.Exp(complex(0, k*c[2]))
+		if got, exp := cylinder.Laplacian(c), 4*f-complex(k*k*c[0]*c[0], 0)*f; cmplx.Abs(got-exp) > 1e-4 {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestComplexVectorOperators(t *testing.T) {
+	var tests = []struct {
+		v      complexVectorField
+		point  []float64
+		expDiv complex128
+		expRot []complex128
+	}{
+		{NewComplexVectorField("exp(i*z)", "0", "0", "car"), []float64{1, 2, 0}, 0, []complex128{0, 1i, 0}},
+		{NewComplexVectorField("i*x", "i*y", "z", "car"), []float64{1, 2, 3}, 1 + 2i, []complex128{0, 0, 0}},
+		{NewComplexVectorField("0", "0", "exp(i*r)", "cyl"), []float64{math.Pi, 1, 0}, 0, []complex128{0, 1i, 0}},
+		{NewComplexVectorField("i*r", "0", "0", "sph"), []float64{1, 1, 1}, 3i, []complex128{0, 0, 0}},
+	}
+	for _, v := range tests {
+		if got := v.v.Div(v.point); cmplx.Abs(got-v.expDiv) > 1e-6 {
+			t.Error("Test failed: {", v.v, v.point, " } inputted, expected {", v.expDiv, "} and got {", got, "}")
+		}
+		if got := v.v.Rot(v.point); !complexEqual(got, v.expRot, 1e-6) {
+			t.Error("Test failed: {", v.v, v.point, " } inputted, expected {", v.expRot, "} and got {", got, "}")
+		}
+	}
+	// The rotation in spherical coordinates agrees with the rotation through cartesian coordinates
+	E := NewComplexVectorField("exp(i*r)*sin(theta)", "i*r*cos(phi)", "r^2*exp(i*theta)", "sph")
+	for _, c := range [][]float64{{1.3, 0.7, 2}, {0.5, 2, -1}} {
+		re := rotCartesian(E.part(realPart).eval, c, "sph")
+		im := rotCartesian(E.part(imagPart).eval, c, "sph")
+		if got, exp := E.Rot(c), complexes(re, im); !complexEqual(got, exp, 1e-5) {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestComplexErrors(t *testing.T) {
+	if _, err := NewComplexScalarField("exp(i*r)", "sph").TryGrad([]float64{0, 1, 1}); err != ErrSingularPoint {
+		t.Error("Test failed: { r=0 } inputted, expected {", ErrSingularPoint, "} and got {", err, "}")
+	}
+	if _, err := NewComplexVectorField("i", "0", "0", "cyl").TryRot([]float64{0, 1, 1}); err != ErrSingularPoint {
+		t.Error("Test failed: { r=0 } inputted, expected {", ErrSingularPoint, "} and got {", err, "}")
+	}
+	if got, err := NewComplexScalarField("i*r^2", "sph", WithSingularity(SingularityCartesian)).TryLaplacian([]float64{0, 1, 1}); err != nil || cmplx.Abs(got-6i) > 1e-4 {
+		t.Error("Test failed: { r=0 } inputted, expected {", 6i, "} and got {", got, err, "}")
+	}
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"i in a real field", func() { NewScalarField("exp(i*x)", "car") }},
+		{"parameter i", func() { NewComplexScalarField("x", "car", WithParams("i")) }},
+		{"other coordinate system", func() { NewComplexVectorField("x", "r", "0", "car") }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
@@ -0,0 +1,121 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// Returns the cartesian coordinates of the point c given in coordsys
+func toCartesian(c []float64, coordsys string) []float64 {
+	switch coordsys {
+	case "car", "car2":
+		return append([]float64(nil), c...)
+	case "cyl":
+		return []float64{c[0] * math.Cos(c[1]), c[0] * math.Sin(c[1]), c[2]}
+	case "sph":
+		return []float64{
+			c[0] * math.Sin(c[1]) * math.Cos(c[2]),
+			c[0] * math.Sin(c[1]) * math.Sin(c[2]),
+			c[0] * math.Cos(c[1])}
+	case "polar":
+		return []float64{c[0] * math.Cos(c[1]), c[0] * math.Sin(c[1])}
+	default:
+		panic("Error converting to cartesian coordinates, coordinates system is wrong")
+	}
+}
+
+// Returns the point p given in cartesian coordinates as coordinates in coordsys
+// Angles are in the range [-pi, pi] and theta in [0, pi]
+func fromCartesian(p []float64, coordsys string) []float64 {
+	switch coordsys {
+	case "car", "car2":
+		return append([]float64(nil), p...)
+	case "cyl":
+		return []float64{math.Hypot(p[0], p[1]), math.Atan2(p[1], p[0]), p[2]}
+	case "sph":
+		r := math.Sqrt(p[0]*p[0] + p[1]*p[1] + p[2]*p[2])
+		return []float64{r, math.Atan2(math.Hypot(p[0], p[1]), p[2]), math.Atan2(p[1], p[0])}
+	case "polar":
+		return []float64{math.Hypot(p[0], p[1]), math.Atan2(p[1], p[0])}
+	default:
+		panic("Error converting from cartesian coordinates, coordinates system is wrong")
+	}
+}
+
+// Returns the unit vectors of coordsys at point c as rows given in cartesian components
+func basis(c []float64, coordsys string) [][]float64 {
+	switch coordsys {
+	case "car":
+		return [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	case "car2":
+		return [][]float64{{1, 0}, {0, 1}}
+	case "cyl":
+		return [][]float64{
+			{math.Cos(c[1]), math.Sin(c[1]), 0},
+			{-math.Sin(c[1]), math.Cos(c[1]), 0},
+			{0, 0, 1}}
+	case "sph":
+		return [][]float64{
+			{math.Sin(c[1]) * math.Cos(c[2]), math.Sin(c[1]) * math.Sin(c[2]), math.Cos(c[1])},
+			{math.Cos(c[1]) * math.Cos(c[2]), math.Cos(c[1]) * math.Sin(c[2]), -math.Sin(c[1])},
+			{-math.Sin(c[2]), math.Cos(c[2]), 0}}
+	case "polar":
+		return [][]float64{
+			{math.Cos(c[1]), math.Sin(c[1])},
+			{-math.Sin(c[1]), math.Cos(c[1])}}
+	default:
+		panic("Error finding unit vectors, coordinates system is wrong")
+	}
+}
+
+// Returns the scale factors of coordsys at point c, the length of a step of one along each coordinate
+func scale(c []float64, coordsys string) []float64 {
+	switch coordsys {
+	case "car":
+		return []float64{1, 1, 1}
+	case "car2":
+		return []float64{1, 1}
+	case "cyl":
+		return []float64{1, c[0], 1}
+	case "sph":
+		return []float64{1, c[0], c[0] * math.Sin(c[1])}
+	case "polar":
+		return []float64{1, c[0]}
+	default:
+		panic("Error finding scale factors, coordinates system is wrong")
+	}
+}
+
+// Returns the cartesian components of the velocity of a point moving through c in coordsys, where d is the
+// derivative of its coordinates
+func velocity(c, d []float64, coordsys string) []float64 {
+	h := scale(c, coordsys)
+	v := make([]float64, len(d))
+	for i := range v {
+		v[i] = h[i] * d[i]
+	}
+	return vecToCartesian(v, c, coordsys)
+}
+
+// Returns the cartesian components of the vector v given in the unit vectors of coordsys at point c
+func vecToCartesian(v, c []float64, coordsys string) []float64 {
+	e := basis(c, coordsys)
+	res := make([]float64, len(v))
+	for i := range e {
+		for j := range res {
+			res[j] += v[i] * e[i][j]
+		}
+	}
+	return res
+}
+
+// Returns the components in the unit vectors of coordsys at point c of the vector v given in cartesian components
+func vecFromCartesian(v, c []float64, coordsys string) []float64 {
+	e := basis(c, coordsys)
+	res := make([]float64, len(v))
+	for i := range e {
+		for j := range v {
+			res[i] += e[i][j] * v[j]
+		}
+	}
+	return res
+}
@@ -0,0 +1,49 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToCartesian(t *testing.T) {
+	var tests = []struct {
+		c        []float64
+		coordsys string
+		exp      []float64
+	}{
+		{[]float64{1, 2, 3}, "car", []float64{1, 2, 3}},
+		{[]float64{2, math.Pi / 2, 3}, "cyl", []float64{0, 2, 3}},
+		{[]float64{2, math.Pi / 2, math.Pi}, "sph", []float64{-2, 0, 0}},
+		{[]float64{1, math.Pi}, "polar", []float64{-1, 0}},
+	}
+	for _, v := range tests {
+		if exp := toCartesian(v.c, v.coordsys); !almostEqual(exp, v.exp, 1e-12) {
+			t.Error("Test failed: {", v.c, v.coordsys, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+		if exp := fromCartesian(v.exp, v.coordsys); !almostEqual(exp, v.c, 1e-12) {
+			t.Error("Test failed: {", v.exp, v.coordsys, " } inputted, expected {", v.c, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestVecToCartesian(t *testing.T) {
+	var tests = []struct {
+		v        []float64
+		c        []float64
+		coordsys string
+		exp      []float64
+	}{
+		{[]float64{1, 2, 3}, []float64{4, 5, 6}, "car", []float64{1, 2, 3}},
+		{[]float64{1, 2, 3}, []float64{4, math.Pi / 2, 6}, "cyl", []float64{-2, 1, 3}},
+		{[]float64{1, 2, 3}, []float64{4, math.Pi / 2, 0}, "sph", []float64{1, 3, -2}},
+		{[]float64{1, 2}, []float64{4, math.Pi}, "polar", []float64{-1, -2}},
+	}
+	for _, v := range tests {
+		if exp := vecToCartesian(v.v, v.c, v.coordsys); !almostEqual(exp, v.exp, 1e-12) {
+			t.Error("Test failed: {", v.v, v.c, v.coordsys, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+		if exp := vecFromCartesian(v.exp, v.c, v.coordsys); !almostEqual(exp, v.v, 1e-12) {
+			t.Error("Test failed: {", v.exp, v.c, v.coordsys, " } inputted, expected {", v.v, "} and got {", exp, "}")
+		}
+	}
+}
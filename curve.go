@@ -0,0 +1,183 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// A curve is a path through space given by the point at each value of its parameter t, in a coordinate system
+// defined as "car", "cyl", "sph", "car2" or "polar"
+// The point is given by the parsed expression of t for each coordinate or by a function of t
+type curve struct {
+	expressions []*node
+	// The derivatives of expressions with respect to t, nil if an expression calls a function without derivative
+	derivatives []*node
+	f           func(t float64) []float64
+	coordsys    string
+	opts        fieldOptions
+}
+
+// Returns a new three-dimensional curve whose coordinates in coordsys are the expressions e1, e2, e3 of the parameter t
+// The options WithParams, WithFuncs and WithStrictSyntax apply, the parameters are bound with Bind or BindParams
+func NewCurve(e1, e2, e3, coordsys string, opts ...FieldOption) curve {
+	if len(coordNames(coordsys)) != 3 {
+		panic("A three-dimensional curve needs the coordinate system car, cyl or sph")
+	}
+	return newCurve([]string{e1, e2, e3}, coordsys, newFieldOptions(opts))
+}
+
+// Returns a new two-dimensional curve whose coordinates in coordsys are the expressions e1, e2 of the parameter t
+func NewCurve2D(e1, e2, coordsys string, opts ...FieldOption) curve {
+	check2D(coordsys)
+	return newCurve([]string{e1, e2}, coordsys, newFieldOptions(opts))
+}
+
+// Returns a new curve whose coordinates in coordsys at the parameter t are f(t)
+func NewCurveFunc(f func(t float64) []float64, coordsys string) curve {
+	if coordNames(coordsys) == nil {
+		panic(coordsErr)
+	}
+	return curve{f: f, coordsys: coordsys}
+}
+
+// Returns the curve with the expressions of the parameter t as coordinates in coordsys and the settings o
+// Panics if an expression uses another name than t, a parameter, a constant or a function
+func newCurve(expressions []string, coordsys string, o fieldOptions) curve {
+	vars := append([]string{timeName}, o.params...)
+	trees := make([]*node, len(expressions))
+	for i, e := range expressions {
+		checkVars(e, vars, o)
+		trees[i] = o.mustParse(e)
+	}
+	return curve{expressions: trees, derivatives: derivatives(trees, timeName), coordsys: coordsys, opts: o}
+}
+
+// Returns a copy of curve where the parameter name has the value value
+func (c curve) Bind(name string, value float64) curve {
+	return c.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of curve where each parameter in values has its value
+func (c curve) BindParams(values map[string]float64) curve {
+	c.opts = c.opts.bind(values)
+	return c
+}
+
+// Returns the coordinates of the point of curve at the parameter t
+func (c curve) point(t float64) []float64 {
+	if c.f != nil {
+		return c.f(t)
+	}
+	p, names := c.opts.withParams([]float64{t}, []string{timeName})
+	res := make([]float64, len(c.expressions))
+	for i, e := range c.expressions {
+		res[i] = c.opts.fnN(p, e, names)
+	}
+	return res
+}
+
+// Returns the cartesian coordinates of the point of curve at the parameter t
+func (c curve) cartesian(t float64) []float64 {
+	return toCartesian(c.point(t), c.coordsys)
+}
+
+// Returns the coordinates in coordsys of the point of curve at the parameter t
+// A curve in coordsys keeps its own coordinates, so that an angle like phi is not moved into [-pi, pi]
+func (c curve) in(t float64, coordsys string) []float64 {
+	if c.coordsys == coordsys {
+		return c.point(t)
+	}
+	return fromCartesian(c.cartesian(t), coordsys)
+}
+
+// Returns the derivatives of trees with respect to name, or nil if a tree can not be differentiated
+func derivatives(trees []*node, name string) []*node {
+	res := make([]*node, len(trees))
+	for i, n := range trees {
+		if !n.differentiable() {
+			return nil
+		}
+		res[i] = n.diff(name)
+	}
+	return res
+}
+
+// Returns the derivative of the cartesian coordinates of curve with respect to its parameter at t
+// The derivative of a curve given by expressions is found symbolically and of a curve given by a function with
+// central differences
+func (c curve) tangent(t float64) []float64 {
+	if c.derivatives != nil {
+		p, names := c.opts.withParams([]float64{t}, []string{timeName})
+		d := make([]float64, len(c.derivatives))
+		for i, e := range c.derivatives {
+			d[i] = c.opts.fnN(p, e, names)
+		}
+		return velocity(c.point(t), d, c.coordsys)
+	}
+	h := 1e-5 * math.Max(1, math.Abs(t))
+	a, b := c.cartesian(t+h), c.cartesian(t-h)
+	res := make([]float64, len(a))
+	for i := range a {
+		res[i] = (a[i] - b[i]) / (2 * h)
+	}
+	return res
+}
+
+// Checks that curve has as many coordinates as a field in coordsys, panics if not
+func (c curve) checkDim(coordsys string) {
+	if len(coordNames(c.coordsys)) != len(coordNames(coordsys)) {
+		panic("The curve is in " + c.coordsys + " and the field in " + coordsys + ", they must have as many coordinates")
+	}
+}
+
+// Returns the integral of F·dl along curve from the parameter t0 to t1, where F takes a point in coordsys and returns
+// the components in its unit vectors, and an estimate of the error of the integral
+func lineIntegral(F func([]float64) []float64, coordsys string, c curve, t0, t1 float64, opts []IntegralOption) (float64, float64) {
+	c.checkDim(coordsys)
+	return integrate(func(t float64) float64 {
+		q := c.in(t, coordsys)
+		v := vecToCartesian(F(q), q, coordsys)
+		var res float64
+		for i, d := range c.tangent(t) {
+			res += v[i] * d
+		}
+		return res
+	}, t0, t1, newIntegralOptions(opts))
+}
+
+// Returns the integral of f times the length of the curve along curve from the parameter t0 to t1, where f takes
+// a point in coordsys, and an estimate of the error of the integral
+func arcIntegral(f func([]float64) float64, coordsys string, c curve, t0, t1 float64, opts []IntegralOption) (float64, float64) {
+	c.checkDim(coordsys)
+	return integrate(func(t float64) float64 {
+		var norm float64
+		for _, d := range c.tangent(t) {
+			norm += d * d
+		}
+		return f(c.in(t, coordsys)) * math.Sqrt(norm)
+	}, t0, t1, newIntegralOptions(opts))
+}
+
+// Calculates the line integral of vectorField along curve from the parameter t0 to t1, the work or circulation of
+// the field along the curve, with adaptive Gauss-Kronrod quadrature
+// Returns the integral and an estimate of its error. The curve can be in another coordinate system with three coordinates
+func (v vectorField) LineIntegral(c curve, t0, t1 float64, opts ...IntegralOption) (float64, float64) {
+	return lineIntegral(v.eval, v.coordsys, c, t0, t1, opts)
+}
+
+// Calculates the integral of scalarField along curve from the parameter t0 to t1 with respect to the arc length,
+// which is the length of the curve for the field 1, like LineIntegral of vectorField
+func (s scalarField) ArcIntegral(c curve, t0, t1 float64, opts ...IntegralOption) (float64, float64) {
+	return arcIntegral(s.eval, s.coordsys, c, t0, t1, opts)
+}
+
+// Calculates the line integral of vectorField2D along the two-dimensional curve from the parameter t0 to t1,
+// like LineIntegral of vectorField
+func (v vectorField2D) LineIntegral(c curve, t0, t1 float64, opts ...IntegralOption) (float64, float64) {
+	return lineIntegral(v.eval, v.coordsys, c, t0, t1, opts)
+}
+
+// Calculates the integral of scalarField2D along the two-dimensional curve from the parameter t0 to t1 with respect
+// to the arc length, like ArcIntegral of scalarField
+func (s scalarField2D) ArcIntegral(c curve, t0, t1 float64, opts ...IntegralOption) (float64, float64) {
+	return arcIntegral(s.eval, s.coordsys, c, t0, t1, opts)
+}
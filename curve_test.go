@@ -0,0 +1,120 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLineIntegral(t *testing.T) {
+	circle := NewCurve("cos(t)", "sin(t)", "0", "car")
+	var tests = []struct {
+		v      vectorField
+		c      curve
+		t0, t1 float64
+		exp    float64
+	}{
+		{NewVectorField("-y", "x", "0", "car"), circle, 0, 2 * math.Pi, 2 * math.Pi},
+		{NewVectorField("0", "r", "0", "cyl"), NewCurve("1", "t", "0", "cyl"), 0, 2 * math.Pi, 2 * math.Pi},
+		// The same circle given in cylinder coordinates for a cartesian field
+		{NewVectorField("-y", "x", "0", "car"), NewCurve("1", "t", "0", "cyl"), 0, 2 * math.Pi, 2 * math.Pi},
+		{NewVectorField("0", "0", "1", "car"), NewCurve("cos(t)", "sin(t)", "t", "car"), 0, 2 * math.Pi, 2 * math.Pi},
+		{NewVectorField("x", "y", "z", "car"), circle, 0, math.Pi, 0},
+	}
+	for _, v := range tests {
+		if got, err := v.v.LineIntegral(v.c, v.t0, v.t1); math.Abs(got-v.exp) > 1e-6 || err > 1e-6 {
+			t.Error("Test failed: {", v.v, v.t0, v.t1, " } inputted, expected {", v.exp, "} and got {", got, err, "}")
+		}
+	}
+	// The line integral of a gradient is the difference of the potential at the end points
+	s := NewScalarField("r^2*cos(theta)+sin(phi)", "sph")
+	grad := NewVectorField("2*r*cos(theta)", "-r*sin(theta)", "cos(phi)/(r*sin(theta))", "sph")
+	path := NewCurveFunc(func(t float64) []float64 {
+		return []float64{1 + t, math.Cos(t) + 1, t * t}
+	}, "car")
+	start, end := fromCartesian(path.point(0), "sph"), fromCartesian(path.point(1), "sph")
+	exp := s.eval(end) - s.eval(start)
+	if got, _ := grad.LineIntegral(path, 0, 1); math.Abs(got-exp) > 1e-6 {
+		t.Error("Test failed: { gradient } inputted, expected {", exp, "} and got {", got, "}")
+	}
+	// A two-dimensional circle with a bound radius
+	R := NewCurve2D("R", "t", "polar", WithParams("R")).Bind("R", 2)
+	if got, _ := NewVectorField2D("-y", "x", "car2").LineIntegral(R, 0, 2*math.Pi); math.Abs(got-8*math.Pi) > 1e-6 {
+		t.Error("Test failed: { R=2 } inputted, expected {", 8*math.Pi, "} and got {", got, "}")
+	}
+}
+
+func TestArcIntegral(t *testing.T) {
+	var tests = []struct {
+		s      scalarField
+		c      curve
+		t0, t1 float64
+		exp    float64
+	}{
+		{NewScalarField("1", "car"), NewCurve("cos(t)", "sin(t)", "t", "car"), 0, 2 * math.Pi, 2 * math.Pi * math.Sqrt2},
+		{NewScalarField("r", "sph"), NewCurve("t", "1", "2", "sph"), 0, 2, 2},
+		{NewScalarField("z^2", "cyl"), NewCurve("0", "0", "t", "car"), -1, 2, 3},
+	}
+	for _, v := range tests {
+		if got, err := v.s.ArcIntegral(v.c, v.t0, v.t1); math.Abs(got-v.exp) > 1e-6 || err > 1e-6 {
+			t.Error("Test failed: {", v.s, v.t0, v.t1, " } inputted, expected {", v.exp, "} and got {", got, err, "}")
+		}
+	}
+	// The circumference of a circle
+	if got, _ := NewScalarField2D("1", "polar").ArcIntegral(NewCurve2D("3", "t", "polar"), 0, 2*math.Pi); math.Abs(got-6*math.Pi) > 1e-6 {
+		t.Error("Test failed: { circle } inputted, expected {", 6*math.Pi, "} and got {", got, "}")
+	}
+}
+
+func TestTangent(t *testing.T) {
+	r := NewFuncRegistry()
+	r.RegisterFunc("cube", 1, func(args ...float64) float64 {
+		return args[0] * args[0] * args[0]
+	})
+	var tests = []struct {
+		c   curve
+		t   float64
+		exp []float64
+		tol float64
+	}{
+		{NewCurve("2", "t", "t", "cyl"), 1, []float64{-2 * math.Sin(1), 2 * math.Cos(1), 1}, 1e-12},
+		{NewCurve("t", "pi/2", "t", "sph"), 2, []float64{math.Cos(2) - 2*math.Sin(2), math.Sin(2) + 2*math.Cos(2), 0}, 1e-12},
+		{NewCurve("t^3", "exp(t)", "0", "car"), 10, []float64{300, math.Exp(10), 0}, 1e-12},
+		{NewCurve2D("t", "k*t", "polar", WithParams("k")).Bind("k", 3), 1, []float64{math.Cos(3) - 3*math.Sin(3), math.Sin(3) + 3*math.Cos(3)}, 1e-12},
+		// A function without derivative and a curve given by a function are differentiated numerically
+		{NewCurve("cube(t)", "0", "0", "car", WithFuncs(r)), 2, []float64{12, 0, 0}, 1e-6},
+		{NewCurveFunc(func(t float64) []float64 { return []float64{t * t, 0, 0} }, "car"), 2, []float64{4, 0, 0}, 1e-6},
+	}
+	for _, v := range tests {
+		got := v.c.tangent(v.t)
+		for i := range got {
+			if math.Abs(got[i]-v.exp[i]) > v.tol*math.Max(1, math.Abs(v.exp[i])) {
+				t.Error("Test failed: {", v.t, " } inputted, expected {", v.exp, "} and got {", got, "}")
+				break
+			}
+		}
+	}
+}
+
+func TestCurveErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"undeclared name", func() { NewCurve("t", "s", "0", "car") }},
+		{"two-dimensional system", func() { NewCurve("t", "0", "0", "polar") }},
+		{"three-dimensional system", func() { NewCurve2D("t", "0", "car") }},
+		{"dimension mismatch", func() {
+			NewVectorField("x", "y", "z", "car").LineIntegral(NewCurve2D("t", "0", "car2"), 0, 1)
+		}},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
@@ -0,0 +1,762 @@
+package vcalc
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The kinds of nodes in the tree of a parsed expression
+const (
+	numNode  = iota // a number with value
+	varNode         // a coordinate or parameter with name
+	negNode         // the negation of args[0]
+	opNode          // the operator op "+", "-", "*", "/", "^" or a comparison applied to args[0] and args[1]
+	callNode        // the function name called with args
+)
+
+// A node in the tree of a parsed expression
+type node struct {
+	kind  int
+	value float64
+	name  string
+	op    string
+	args  []*node
+	// The custom function called by a callNode, nil for the built-in functions
+	fn *function
+	// True for a multiplication which was not written with * but inferred when parsing
+	implicit bool
+}
+
+// The constants known in expressions by their names
+var constants = map[string]float64{
+	"pi": math.Pi,
+}
+
+// Returns true if name is the name of a constant
+func isConst(name string) bool {
+	_, ok := constants[name]
+	return ok
+}
+
+// Returns a new node holding the number value
+func num(value float64) *node {
+	return &node{kind: numNode, value: value}
+}
+
+// Returns a new node holding the coordinate or parameter name
+func variable(name string) *node {
+	return &node{kind: varNode, name: name}
+}
+
+// Returns a new node calling the function name with args
+func call(name string, args ...*node) *node {
+	return &node{kind: callNode, name: name, args: args}
+}
+
+// Returns true if n is the number value
+func (n *node) is(value float64) bool {
+	return n.kind == numNode && n.value == value
+}
+
+// Returns the names of the coordinates, parameters and constants in the tree n in the order they are found
+func (n *node) variables() []string {
+	if n.kind == varNode {
+		return []string{n.name}
+	}
+	var res []string
+	for _, arg := range n.args {
+		res = append(res, arg.variables()...)
+	}
+	return res
+}
+
+// Returns true if the tree n contains the coordinate or parameter name
+func (n *node) uses(name string) bool {
+	if n.kind == varNode {
+		return n.name == name
+	}
+	for _, arg := range n.args {
+		if arg.uses(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the negation of a, folding numbers and double negations
+func neg(a *node) *node {
+	switch {
+	case a.kind == numNode:
+		return num(-a.value)
+	case a.kind == negNode:
+		return a.args[0]
+	}
+	return &node{kind: negNode, args: []*node{a}}
+}
+
+// Returns the operator op applied to a and b
+// Operations on two numbers are folded and additions of zero and multiplications by zero and one are removed
+func op(o string, a, b *node) *node {
+	if a.kind == numNode && b.kind == numNode && o != "^" {
+		return num(getOPR(o, a.value, b.value))
+	}
+	switch o {
+	case "+":
+		if a.is(0) {
+			return b
+		} else if b.is(0) {
+			return a
+		} else if b.kind == negNode {
+			return op("-", a, b.args[0])
+		}
+	case "-":
+		if b.is(0) {
+			return a
+		} else if a.is(0) {
+			return neg(b)
+		} else if b.kind == negNode {
+			return op("+", a, b.args[0])
+		}
+	case "*":
+		if a.is(0) || b.is(0) {
+			return num(0)
+		} else if a.is(1) {
+			return b
+		} else if b.is(1) {
+			return a
+		} else if a.is(-1) {
+			return neg(b)
+		} else if b.is(-1) {
+			return neg(a)
+		}
+	case "/":
+		if a.is(0) {
+			return num(0)
+		} else if b.is(1) {
+			return a
+		}
+	case "^":
+		if b.is(0) {
+			return num(1)
+		} else if b.is(1) {
+			return a
+		}
+	}
+	return &node{kind: opNode, op: o, args: []*node{a, b}}
+}
+
+// Returns the operator o applied to the numbers a and b
+// A power of a negative base is only real for an integer exponent, any other exponent gives NaN
+func getOPR(o string, a, b float64) float64 {
+	switch o {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	case "^":
+		return math.Pow(a, b)
+	case "<":
+		return boolean(a < b)
+	case ">":
+		return boolean(a > b)
+	case "<=":
+		return boolean(a <= b)
+	case ">=":
+		return boolean(a >= b)
+	case "==":
+		return boolean(a == b)
+	case "!=":
+		return boolean(a != b)
+	default:
+		panic("Error finding operator " + o)
+	}
+}
+
+// Returns 1 if b is true and 0 if not, the value of a comparison
+func boolean(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Returns true if o is one of the comparison operators "<", ">", "<=", ">=", "==" or "!="
+func isComparison(o string) bool {
+	switch o {
+	case "<", ">", "<=", ">=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+// Returns the calculation of the tree n at the point c, where c[i] is the value of the coordinate coords[i]
+func (n *node) eval(c []float64, coords []string) float64 {
+	switch n.kind {
+	case numNode:
+		return n.value
+	case varNode:
+		if value, ok := constants[n.name]; ok {
+			return value
+		}
+		return getCOORDN(c, n.name, coords)
+	case negNode:
+		return -n.args[0].eval(c, coords)
+	case opNode:
+		return getOPR(n.op, n.args[0].eval(c, coords), n.args[1].eval(c, coords))
+	default:
+		args := make([]float64, len(n.args))
+		for i, arg := range n.args {
+			args[i] = arg.eval(c, coords)
+		}
+		if n.fn != nil {
+			return n.fn.eval(args...)
+		}
+		return getFUNC(n.name, args...)
+	}
+}
+
+// Returns the symbolic derivative of the tree n with respect to the coordinate or parameter name
+func (n *node) diff(name string) *node {
+	switch n.kind {
+	case numNode:
+		return num(0)
+	case varNode:
+		if n.name == name {
+			return num(1)
+		}
+		return num(0)
+	case negNode:
+		return neg(n.args[0].diff(name))
+	case opNode:
+		a, b := n.args[0], n.args[1]
+		switch n.op {
+		case "+", "-":
+			return op(n.op, a.diff(name), b.diff(name))
+		case "*":
+			return op("+", op("*", a.diff(name), b), op("*", a, b.diff(name)))
+		case "/":
+			return op("/", op("-", op("*", a.diff(name), b), op("*", a, b.diff(name))), op("^", b, num(2)))
+		case "<", ">", "<=", ">=", "==", "!=":
+			// A comparison is constant away from the boundary where it switches
+			return num(0)
+		default:
+			db := b.diff(name)
+			if db.is(0) {
+				// The exponent is constant, d(a^b) = b*a^(b-1)*da
+				return op("*", op("*", b, op("^", a, op("-", b, num(1)))), a.diff(name))
+			}
+			// d(a^b) = a^b*(db*ln(a) + b*da/a)
+			return op("*", n, op("+", op("*", db, call("ln", a)), op("/", op("*", b, a.diff(name)), a)))
+		}
+	default:
+		f := functions[n.name]
+		if n.fn != nil {
+			f = *n.fn
+		}
+		if f.piecewise {
+			// Away from the boundaries of the conditions the derivative is the derivative of the chosen value
+			args := append([]*node(nil), n.args...)
+			for i := range args {
+				if i%2 == 1 || i == len(args)-1 {
+					args[i] = args[i].diff(name)
+				}
+			}
+			return &node{kind: callNode, name: n.name, args: args, fn: n.fn}
+		}
+		if f.deriv == nil {
+			panic("Function " + n.name + " has no derivative rule")
+		}
+		res := num(0)
+		for i, partial := range f.deriv(n.args) {
+			res = op("+", res, op("*", partial, n.args[i].diff(name)))
+		}
+		return res
+	}
+}
+
+// Returns true if the tree n can be differentiated with diff, that is every function it calls has a derivative rule
+func (n *node) differentiable() bool {
+	if n.kind == callNode {
+		f := functions[n.name]
+		if n.fn != nil {
+			f = *n.fn
+		}
+		if f.deriv == nil && !f.piecewise {
+			return false
+		}
+	}
+	for _, arg := range n.args {
+		if !arg.differentiable() {
+			return false
+		}
+	}
+	return true
+}
+
+// Binding strength of each kind of node when printed, a higher value binds harder
+func (n *node) precedence() int {
+	switch {
+	case n.kind == opNode && isComparison(n.op):
+		return 0
+	case n.kind == opNode && (n.op == "+" || n.op == "-"):
+		return 1
+	case n.kind == opNode && (n.op == "*" || n.op == "/"):
+		return 2
+	case n.kind == negNode || (n.kind == numNode && n.value < 0):
+		return 3
+	case n.kind == opNode:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// Returns the tree n as an expression string which is parsed back into the same tree,
+// every multiplication is written with * also when it was inferred
+func (n *node) String() string {
+	return n.format("*")
+}
+
+// Returns the tree n as an expression string like String, where every inferred multiplication is written as ·
+// to show how an expression using implicit multiplication was read
+func (n *node) pretty() string {
+	return n.format("·")
+}
+
+// Returns the tree n as an expression string, where an inferred multiplication is written as implicit
+func (n *node) format(implicit string) string {
+	switch n.kind {
+	case numNode:
+		return strconv.FormatFloat(n.value, 'f', -1, 64)
+	case varNode:
+		return n.name
+	case negNode:
+		return "-" + n.args[0].child(3, false, implicit)
+	case opNode:
+		if n.op == "^" {
+			// ^ is right associative and its exponent can be negated without parentheses, like x^-2
+			return n.args[0].child(4, true, implicit) + "^" + n.args[1].child(3, false, implicit)
+		}
+		o := n.op
+		if n.implicit {
+			o = implicit
+		}
+		// Comparisons can not be chained, so both operands of a comparison are strict
+		p := n.precedence()
+		return n.args[0].child(p, p == 0, implicit) + o + n.args[1].child(p, true, implicit)
+	default:
+		args := make([]string, len(n.args))
+		for i, arg := range n.args {
+			args[i] = arg.format(implicit)
+		}
+		return n.name + "(" + strings.Join(args, ", ") + ")"
+	}
+}
+
+// Returns n as operand of a node with precedence p, in parentheses if it binds weaker
+// or as hard as the operator when strict is true
+func (n *node) child(p int, strict bool, implicit string) string {
+	if q := n.precedence(); q < p || (strict && q == p) {
+		return "(" + n.format(implicit) + ")"
+	}
+	return n.format(implicit)
+}
+
+// A token of an expression, kind is 'n' for numbers, 'a' for names, 'c' for comparisons and the character itself for other operators
+type token struct {
+	kind  byte
+	text  string
+	value float64
+	pos   int
+}
+
+// Returns true if ch is an ASCII letter, which names of coordinates, parameters and functions are made of
+func isLetter(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// Returns true if ch is an ASCII digit
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// Returns the tokens of expression, where the position of a token is the number of characters before it
+// A number is digits with an optional decimal point and an optional exponent like 1e-3 or 2.5E+4, where the e must
+// be followed by digits, so that 3exp(x) is still 3 times exp(x)
+func lex(expression string) ([]token, error) {
+	var tokens []token
+	// The character at the byte i, its size in bytes and its position
+	at := func(i int) (rune, int) {
+		if i >= len(expression) {
+			return 0, 0
+		}
+		return utf8.DecodeRuneInString(expression[i:])
+	}
+	for i, pos := 0, 0; i < len(expression); pos++ {
+		ch, size := at(i)
+		start := i
+		switch {
+		case unicode.IsSpace(ch):
+			i += size
+		case isDigit(ch) || ch == '.':
+			for ch, _ = at(i); isDigit(ch) || ch == '.'; ch, _ = at(i) {
+				i++
+			}
+			if ch == 'e' || ch == 'E' {
+				j := i + 1
+				if sign, _ := at(j); sign == '+' || sign == '-' {
+					j++
+				}
+				if digit, _ := at(j); isDigit(digit) {
+					for i = j; isDigit(digit); digit, _ = at(i) {
+						i++
+					}
+				}
+			}
+			value, err := strconv.ParseFloat(expression[start:i], 64)
+			if err != nil {
+				return nil, parseError(expression, pos, "invalid number "+expression[start:i])
+			}
+			tokens = append(tokens, token{kind: 'n', text: expression[start:i], value: value, pos: pos})
+		case isLetter(ch):
+			for ch, _ = at(i); isLetter(ch); ch, _ = at(i) {
+				i++
+			}
+			for ; isDigit(ch); ch, _ = at(i) {
+				i++
+			}
+			tokens = append(tokens, token{kind: 'a', text: expression[start:i], pos: pos})
+		case strings.ContainsRune("<>=!", ch):
+			i++
+			if next, _ := at(i); next == '=' {
+				i++
+			}
+			if o := expression[start:i]; o == "=" || o == "!" {
+				return nil, parseError(expression, pos, "unexpected character "+o+", comparisons are <, >, <=, >=, == and !=")
+			}
+			tokens = append(tokens, token{kind: 'c', text: expression[start:i], pos: pos})
+		case strings.ContainsRune("+-*/^(),", ch):
+			tokens = append(tokens, token{kind: byte(ch), text: string(ch), pos: pos})
+			i++
+		default:
+			return nil, parseError(expression, pos, "unexpected character "+string(ch))
+		}
+		// Every character of a token but the first one is counted here, the first one by the loop
+		pos += utf8.RuneCountInString(expression[start:i]) - 1
+	}
+	return append(tokens, token{kind: 0, text: "end of expression", pos: utf8.RuneCountInString(expression)}), nil
+}
+
+// An error in the syntax of an expression
+type syntaxError struct {
+	expression string
+	pos        int
+	msg        string
+}
+
+func (e *syntaxError) Error() string {
+	return "Error parsing expression \"" + e.expression + "\" at position " + strconv.Itoa(e.pos) + ": " + e.msg
+}
+
+// Returns a syntaxError at position pos of expression
+func parseError(expression string, pos int, msg string) error {
+	return &syntaxError{expression, pos, msg}
+}
+
+// A recursive descent parser of expressions following the grammar
+//
+//	comparison = expr [("<" | ">" | "<=" | ">=" | "==" | "!=") expr]
+//	expr   = term {("+" | "-") term}
+//	term   = unary {("*" | "/") unary | factor}
+//	unary  = ("+" | "-") unary | factor
+//	factor = number name-power | power
+//	power  = primary ["^" exponent]
+//	exponent = ("+" | "-") exponent | power
+//	primary = number | name | function "(" comparison {"," comparison} ")" | "(" comparison ")"
+//
+// where a number directly followed by a name is a coefficient multiplying it, like 3x^2 or 5cos(y),
+// a factor directly following another is multiplied with it, like 2pi r, x sin(y), 2(x+1) or x(y+1) when x is
+// not a function,
+// and ^ is right associative binding harder than a sign in its exponent, so 2^-x^2 is 2^(-(x^2))
+// The inferred multiplications bind like *, but a coefficient binds harder, so 1/2x is 1/(2*x)
+// In strict mode neither coefficients nor factors following each other are allowed
+type parser struct {
+	expression string
+	tokens     []token
+	pos        int
+	funcs      funcRegistry
+	strict     bool
+}
+
+// Returns the tree of the parsed expression, an empty expression is the number zero
+func parse(expression string) (*node, error) {
+	return fieldOptions{}.parse(expression)
+}
+
+// Returns the tree of the parsed expression, panics if the expression has a syntax error
+func mustParse(expression string) *node {
+	return fieldOptions{}.mustParse(expression)
+}
+
+// Returns the tree of the parsed expression with the settings o, which can call the built-in functions
+// and those in the registry of o and only use implicit multiplication if o is not strict
+func (o fieldOptions) parse(expression string) (*node, error) {
+	if strings.TrimSpace(expression) == "" {
+		return num(0), nil
+	}
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expression: expression, tokens: tokens, funcs: o.funcs, strict: o.strict}
+	n, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != 0 {
+		return nil, p.error("unexpected " + p.peek().text)
+	}
+	return n, nil
+}
+
+// Returns the tree of the parsed expression like parse, panics if the expression has a syntax error
+func (o fieldOptions) mustParse(expression string) *node {
+	n, err := o.parse(expression)
+	if err != nil {
+		panic(err.Error())
+	}
+	return n
+}
+
+// Returns the boundaries where the comparisons in the expressions switch as equations like r=1,
+// each boundary is given once in the order it is first found
+func (o fieldOptions) discontinuities(expressions ...string) []string {
+	var res []string
+	found := map[string]bool{}
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.kind == opNode && isComparison(n.op) {
+			if b := n.args[0].String() + "=" + n.args[1].String(); !found[b] {
+				found[b] = true
+				res = append(res, b)
+			}
+		}
+		for _, arg := range n.args {
+			walk(arg)
+		}
+	}
+	for _, e := range expressions {
+		walk(o.mustParse(e))
+	}
+	return res
+}
+
+// Returns the expression parsed with the options opts and printed with every multiplication inferred from
+// a coefficient or from factors following each other written as ·, and parentheses where they are needed
+// Returns an error if the expression has a syntax error
+func ExplainExpression(expression string, opts ...FieldOption) (string, error) {
+	n, err := newFieldOptions(opts).parse(expression)
+	if err != nil {
+		return "", err
+	}
+	return n.pretty(), nil
+}
+
+// Returns the current token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// Returns the current token and moves to the next
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != 0 {
+		p.pos++
+	}
+	return t
+}
+
+// Returns a syntaxError at the current token
+func (p *parser) error(msg string) error {
+	return parseError(p.expression, p.peek().pos, msg)
+}
+
+// Returns the negation of n as parsed, where only a negated number is folded so that -2 is the number -2
+func negate(n *node) *node {
+	if n.kind == numNode {
+		return num(-n.value)
+	}
+	return &node{kind: negNode, args: []*node{n}}
+}
+
+func (p *parser) comparison() (*node, error) {
+	n, err := p.expr()
+	if err != nil || p.peek().kind != 'c' {
+		return n, err
+	}
+	o := p.next().text
+	b, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind == 'c' {
+		return nil, p.error("comparisons can not be chained, use if or piecewise to combine them")
+	}
+	return &node{kind: opNode, op: o, args: []*node{n, b}}, nil
+}
+
+func (p *parser) expr() (*node, error) {
+	n, err := p.term()
+	for err == nil && (p.peek().kind == '+' || p.peek().kind == '-') {
+		o := p.next().text
+		var b *node
+		if b, err = p.term(); err == nil {
+			n = &node{kind: opNode, op: o, args: []*node{n, b}}
+		}
+	}
+	return n, err
+}
+
+func (p *parser) term() (*node, error) {
+	n, err := p.unary()
+	for err == nil {
+		var b *node
+		switch t := p.peek(); {
+		case t.kind == '*' || t.kind == '/':
+			p.next()
+			if b, err = p.unary(); err == nil {
+				n = &node{kind: opNode, op: t.text, args: []*node{n, b}}
+			}
+		case t.kind == 'n' || t.kind == 'a' || t.kind == '(':
+			// A factor directly following another is multiplied with it, like x y, 2(x+1) or x sin(y)
+			if p.strict {
+				return nil, p.error("expected an operator before " + t.text + ", implicit multiplication is not allowed")
+			}
+			if b, err = p.unary(); err == nil {
+				n = &node{kind: opNode, op: "*", args: []*node{n, b}, implicit: true}
+			}
+		default:
+			return n, nil
+		}
+	}
+	return n, err
+}
+
+func (p *parser) unary() (*node, error) {
+	switch p.peek().kind {
+	case '-':
+		p.next()
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negate(n), nil
+	case '+':
+		p.next()
+		return p.unary()
+	case 'n':
+		if p.tokens[p.pos+1].kind == 'a' && !p.strict {
+			coef := num(p.next().value)
+			n, err := p.power()
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: opNode, op: "*", args: []*node{coef, n}, implicit: true}, nil
+		}
+	}
+	return p.power()
+}
+
+func (p *parser) power() (*node, error) {
+	n, err := p.primary()
+	if err != nil || p.peek().kind != '^' {
+		return n, err
+	}
+	p.next()
+	b, err := p.exponent()
+	if err != nil {
+		return nil, err
+	}
+	return &node{kind: opNode, op: "^", args: []*node{n, b}}, nil
+}
+
+func (p *parser) exponent() (*node, error) {
+	switch p.peek().kind {
+	case '-':
+		p.next()
+		n, err := p.exponent()
+		if err != nil {
+			return nil, err
+		}
+		return negate(n), nil
+	case '+':
+		p.next()
+		return p.exponent()
+	}
+	return p.power()
+}
+
+func (p *parser) primary() (*node, error) {
+	t := p.peek()
+	switch t.kind {
+	case 'n':
+		p.next()
+		return num(t.value), nil
+	case 'a':
+		p.next()
+		f, ok := p.funcs.lookup(t.text)
+		switch {
+		case ok && p.peek().kind != '(':
+			return nil, parseError(p.expression, t.pos, "function "+t.text+" must be called with parentheses")
+		case !ok && p.peek().kind == '(' && p.strict:
+			return nil, parseError(p.expression, t.pos, "unknown function "+t.text)
+		case !ok:
+			// A name which is not a function followed by parentheses is multiplied with them by term, like x(y+1)
+			return variable(t.text), nil
+		}
+		p.next()
+		var args []*node
+		for {
+			arg, err := p.comparison()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != ',' {
+				break
+			}
+			p.next()
+		}
+		if p.peek().kind != ')' {
+			return nil, p.error("expected ) after arguments of " + t.text)
+		}
+		p.next()
+		if (f.arity >= 0 && len(args) != f.arity) || (f.arity < 0 && len(args) < -f.arity) {
+			return nil, parseError(p.expression, t.pos, "wrong number of arguments to "+t.text)
+		}
+		if f.piecewise && len(args)%2 == 0 {
+			return nil, parseError(p.expression, t.pos, t.text+" takes conditions and values in pairs followed by a default value")
+		}
+		return p.funcs.call(t.text, args...), nil
+	case '(':
+		p.next()
+		n, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != ')' {
+			return nil, p.error("expected )")
+		}
+		p.next()
+		return n, nil
+	default:
+		return nil, p.error("unexpected " + t.text)
+	}
+}
@@ -0,0 +1,307 @@
+package vcalc
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		expression string
+		exp        string
+	}{
+		{"", "0"},
+		{"72+3x^2+5cos(y^2)-3z", "72+3*x^2+5*cos(y^2)-3*z"},
+		{"-3sin(2r^3)^5+phi*theta^2", "-(3*sin(2*r^3)^5)+phi*theta^2"},
+		{"sqrt(1-theta^2)-5phi+3", "sqrt(1-theta^2)-5*phi+3"},
+		{"atan2(y, x)*min(x,y,z)", "atan2(y, x)*min(x, y, z)"},
+		{"x-(y-z)", "x-(y-z)"},
+		{"(x*y)^2/-z", "(x*y)^2/-z"},
+		{" 2.5 x / ( y + 1 ) ", "2.5*x/(y+1)"},
+		{"1e-3x", "0.001*x"},
+		{"2.5E+2+1.5e2y", "250+150*y"},
+		{"3exp(x)-2e", "3*exp(x)-2*e"},
+		{"r^-2", "r^-2"},
+		{"x^0.5+2^x", "x^0.5+2^x"},
+		{"y^(z+1)", "y^(z+1)"},
+		{"2^3^2", "2^3^2"},
+		{"(2^3)^2", "(2^3)^2"},
+		{"x^-y^2", "x^-y^2"},
+		{"(-x)^2*(-2)^y", "(-x)^2*(-2)^y"},
+		{"x^(-2*y)", "x^(-2*y)"},
+		{"3x^+2", "3*x^2"},
+		{"if(r<1, r^2/2, 1/r)", "if(r<1, r^2/2, 1/r)"},
+		{"(x<1)*x+(y>=2)", "(x<1)*x+(y>=2)"},
+		{"piecewise(x<0, -x, x!=1, x^2, 1)", "piecewise(x<0, -x, x!=1, x^2, 1)"},
+		{"x+1<=2y", "x+1<=2*y"},
+		{"(x<y)==(y>z)", "(x<y)==(y>z)"},
+	}
+	for _, v := range tests {
+		n, err := parse(v.expression)
+		if err != nil || n.String() != v.exp {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", n, err, "}")
+			continue
+		}
+		// The printed expression is parsed back into the same tree
+		if m := mustParse(n.String()); m.String() != n.String() {
+			t.Error("Test failed: {", n, " } inputted, expected {", n, "} and got {", m, "}")
+		}
+	}
+}
+
+func TestParseTerms(t *testing.T) {
+	// The terms of a sum and the signs between them, where the first sign is empty for a term without sign
+	var tests = []struct {
+		expression string
+		ops        []string
+		terms      []string
+	}{
+		{"", []string{""}, []string{"0"}},
+		{"72+3x^2+5cos(y^2)-3z", []string{"", "+", "+", "-"}, []string{"72", "3*x^2", "5*cos(y^2)", "3*z"}},
+		{"-3sin(2r^3)^5+phi*theta^2", []string{"-", "+"}, []string{"3*sin(2*r^3)^5", "phi*theta^2"}},
+		{"3+35-43/54+cos(3x^3)^3", []string{"", "+", "-", "+"}, []string{"3", "35", "43/54", "cos(3*x^3)^3"}},
+		{"-3cos(x)-65y+theta/phi", []string{"-", "-", "+"}, []string{"3*cos(x)", "65*y", "theta/phi"}},
+		{"x-(y-z)", []string{"", "-"}, []string{"x", "y-z"}},
+	}
+	for _, v := range tests {
+		ops, terms := splitSum(mustParse(v.expression))
+		if !reflect.DeepEqual(ops, v.ops) || !reflect.DeepEqual(terms, v.terms) {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.ops, v.terms, "} and got {", ops, terms, "}")
+		}
+	}
+}
+
+// Returns the signs and the terms of the sum n
+func splitSum(n *node) ([]string, []string) {
+	switch {
+	case n.kind == opNode && (n.op == "+" || n.op == "-"):
+		ops, terms := splitSum(n.args[0])
+		return append(ops, n.op), append(terms, n.args[1].String())
+	case n.kind == negNode:
+		return []string{"-"}, []string{n.args[0].String()}
+	default:
+		return []string{""}, []string{n.String()}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	// A number is its value and a coefficient multiplies what follows it, which is 1 without a coefficient
+	var tests = []struct {
+		expression string
+		exp        float64
+	}{
+		{"1", 1},
+		{"-4", -4},
+		{"3.43", 3.43},
+		{"0", 0},
+		{"x", 1},
+		{"-4x", -4},
+		{"3.43x", 3.43},
+		{"0x", 0},
+	}
+	for _, v := range tests {
+		if exp := mustParse(v.expression).eval([]float64{1}, []string{"x"}); exp != v.exp {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	var tests = []string{
+		"x+",
+		"sin x",
+		"(x+y",
+		"atan2(x)",
+		"min(x)",
+		"x^",
+		"x^*2",
+		"2^-",
+		"3$x",
+		"pi()",
+		"x<y<z",
+		"x=1",
+		"!x",
+		"if(x<1, 2)",
+		"piecewise(x<1, 2, x<2, 3)",
+	}
+	for _, v := range tests {
+		if n, err := parse(v); err == nil {
+			t.Error("Test failed: {", v, " } inputted, expected an error and got {", n, "}")
+		}
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	var tests = []struct {
+		expression string
+		exp        string
+	}{
+		{"2*θ", "at position 2: unexpected character θ"},
+		{"θ^2+φ", "at position 0: unexpected character θ"},
+		{"sin(θ)+$", "at position 4: unexpected character θ"},
+		{"(y+1)-1.2.3", "at position 6: invalid number 1.2.3"},
+	}
+	for _, v := range tests {
+		_, err := parse(v.expression)
+		if err == nil || !strings.HasSuffix(err.Error(), v.exp) {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", err, "}")
+		}
+	}
+}
+
+func TestExplainExpression(t *testing.T) {
+	var tests = []struct {
+		expression string
+		exp        string
+	}{
+		{"2pi r", "2·pi·r"},
+		{"x y", "x·y"},
+		{"2(x+1)", "2·(x+1)"},
+		{"x sin(y)", "x·sin(y)"},
+		{"1/2x", "1/(2·x)"},
+		{"1/2 x", "1/(2·x)"},
+		{"1/2*x", "1/2*x"},
+		{"3x^2 y", "3·x^2·y"},
+		{"x*y", "x*y"},
+		{"(x+1)(x-1)", "(x+1)·(x-1)"},
+		{"2^x y", "2^x·y"},
+		{"-x y", "-x·y"},
+		{"2pi(r+1)", "2·pi·(r+1)"},
+		{"x y/z", "x·y/z"},
+		{"x/y z", "x/y·z"},
+		{"x (y+1)", "x·(y+1)"},
+		{"r(z+1)", "r·(z+1)"},
+		{"3x(y+1)^2", "3·x·(y+1)^2"},
+		{"foo(x)", "foo·x"},
+		{"x sin(y)(z-1)", "x·sin(y)·(z-1)"},
+	}
+	for _, v := range tests {
+		exp, err := ExplainExpression(v.expression)
+		if err != nil || exp != v.exp {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, err, "}")
+		}
+	}
+}
+
+func TestStrictSyntax(t *testing.T) {
+	var tests = []struct {
+		expression string
+		valid      bool
+	}{
+		{"3*x^2+2*pi*r", true},
+		{"3x", false},
+		{"x y", false},
+		{"2(x+1)", false},
+		{"x sin(y)", false},
+		{"x(y+1)", false},
+		{"foo(x)", false},
+	}
+	for _, v := range tests {
+		if _, err := ExplainExpression(v.expression, WithStrictSyntax()); (err == nil) != v.valid {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.valid, "} and got {", err, "}")
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	var tests = []struct {
+		expression string
+		c          []float64
+		exp        float64
+	}{
+		{"3^2", []float64{0, 0, 0}, 9},
+		{"-x^2", []float64{3, 0, 0}, -9},
+		{"2*-x", []float64{3, 0, 0}, -6},
+		{"x/y/z", []float64{8, 2, 2}, 2},
+		{"x-y-z", []float64{8, 2, 2}, 4},
+		{"ln(x)+log10(y)", []float64{math.E, 100, 0}, 3},
+		{"atan2(y, x)", []float64{-1, 0, 0}, math.Pi},
+		{"max(x, y, z)-min(x, y, z)", []float64{1, 5, -2}, 7},
+		{"pow(x, 0.5)+abs(y)+sign(z)", []float64{4, -3, -0.1}, 4},
+		{"cosh(x)^2-sinh(x)^2", []float64{1.5, 0, 0}, 1},
+		{"tanh(atanh(z))", []float64{0, 0, 0.5}, 0.5},
+		{"2^3^2", []float64{0, 0, 0}, 512},
+		{"x^-2", []float64{2, 0, 0}, 0.25},
+		{"2^-x^2", []float64{1, 0, 0}, 0.5},
+		{"y^(z+1)", []float64{0, 3, 1}, 9},
+		{"x^0.5", []float64{16, 0, 0}, 4},
+		{"(-2)^3", []float64{0, 0, 0}, -8},
+		{"2pi x", []float64{0.5, 0, 0}, math.Pi},
+		{"x y z", []float64{2, 3, 4}, 24},
+		{"2(x+1)y", []float64{1, 3, 0}, 12},
+		{"x(y+1)", []float64{2, 3, 0}, 8},
+		{"y(z+1)x", []float64{2, 3, 4}, 30},
+		{"if(x<1, y, z)", []float64{0, 3, 4}, 3},
+		{"if(x<1, y, z)", []float64{1, 3, 4}, 4},
+		{"piecewise(x<0, 1, x<2, 2, 3)", []float64{1, 0, 0}, 2},
+		{"piecewise(x<0, 1, x<2, 2, 3)", []float64{5, 0, 0}, 3},
+		{"(x>=1)+(x<=1)+(x==1)+(x!=1)", []float64{1, 0, 0}, 3},
+	}
+	for _, v := range tests {
+		if exp := mustParse(v.expression).eval(v.c, []string{"x", "y", "z"}); math.Abs(exp-v.exp) > 1e-12 {
+			t.Error("Test failed: {", v.expression, v.c, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestPowerNegativeBase(t *testing.T) {
+	// A negative base with a fractional exponent has no real value and gives NaN, like math.Pow
+	var tests = []string{"x^0.5", "x^(1/3)", "x^y"}
+	for _, v := range tests {
+		if exp := mustParse(v).eval([]float64{-8, 0.5, 0}, []string{"x", "y", "z"}); !math.IsNaN(exp) {
+			t.Error("Test failed: {", v, " } inputted, expected {", math.NaN(), "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var tests = []struct {
+		expression string
+		name       string
+		exp        string
+	}{
+		{"3x^2+y", "x", "3*(2*x)"},
+		{"3x^2+y", "y", "1"},
+		{"sin(x*y)", "x", "cos(x*y)*y"},
+		{"ln(x)", "x", "1/x"},
+		{"x/y", "y", "-x/y^2"},
+		{"exp(2x)", "x", "exp(2*x)*2"},
+		{"r^-2", "r", "-2*r^-3"},
+		{"x^y", "y", "x^y*ln(x)"},
+		{"x^y", "x", "y*x^(y-1)"},
+		{"2^x", "x", "2^x*ln(2)"},
+	}
+	for _, v := range tests {
+		if exp := mustParse(v.expression).diff(v.name).String(); exp != v.exp {
+			t.Error("Test failed: {", v.expression, v.name, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestDiffFunctions(t *testing.T) {
+	// Every derivative rule is compared to a central difference at a point inside the domain of every function
+	var tests = []string{
+		"sin(x)", "cos(x)", "tan(x)", "exp(x)", "sqrt(x)", "log(x)", "ln(x)", "log10(x)", "abs(x)", "sign(x)",
+		"asin(x)", "acos(x)", "atan(x)", "sinh(x)", "cosh(x)", "tanh(x)", "asinh(x)", "acosh(x+1)", "atanh(x)",
+		"atan2(x, y)", "atan2(y, x)", "pow(x, y)", "pow(y, x)", "min(x, y)", "max(x, y)", "min(y, x, 2)", "x^3/y",
+		"x^y", "y^(x+1)", "(x+1)^-2", "2^(x*y)", "x^0.5", "(x+y)^x^y",
+		"if(x<0.5, x^2, y*x)", "if(x>0.5, x^2, y*x)", "piecewise(x<0, -x, y<1, x^2*y, 1)", "(x<y)*x",
+	}
+	coords := []string{"x", "y"}
+	c := []float64{0.3, 0.7}
+	h := 0.0001
+	for _, v := range tests {
+		n := mustParse(v)
+		if _, ok := functions[n.name]; !ok && n.kind == callNode {
+			t.Error("Test failed: {", v, " } inputted, unknown function")
+		}
+		for i, name := range coords {
+			exp := (n.eval(shift(c, i, h), coords) - n.eval(shift(c, i, -h), coords)) / (2 * h)
+			if d := n.diff(name).eval(c, coords); math.Abs(d-exp) > 1e-6 {
+				t.Error("Test failed: {", v, name, " } inputted, expected {", exp, "} and got {", d, "} from {", n.diff(name), "}")
+			}
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package vcalc
+
+// A two-dimensional scalar field has a mathematical expression as string and
+// a coordinate system defined as "car2" for cartesian, "polar" for polar coordinates
+type scalarField2D struct {
+	expression string
+	tree       *node
+	coordsys   string
+	opts       fieldOptions
+}
+
+// A two-dimensional vector field has a mathematical expression for each coordinate in 2-dimensional space and
+// a coordinate system defined as "car2" for cartesian, "polar" for polar coordinates
+type vectorField2D struct {
+	expressionCoord1 string
+	expressionCoord2 string
+	treeCoord1       *node
+	treeCoord2       *node
+	coordsys         string
+	opts             fieldOptions
+}
+
+// Returns a new two-dimensional scalar field, the options opts change the default settings of the field
+func NewScalarField2D(expression string, coordsys string, opts ...FieldOption) scalarField2D {
+	s := scalarField2D{}
+	s.coordsys = coordsys
+	s.opts = newFieldOptions(opts)
+	check2D(coordsys)
+	checkCoords(expression, coordsys, s.opts)
+	return s.withExpression(expression)
+}
+
+// Returns scalarField2D with the expression e and its tree like withExpression of scalarField
+func (s scalarField2D) withExpression(e string) scalarField2D {
+	s.expression = e
+	s.tree = s.opts.mustParse(e)
+	return s
+}
+
+// Returns the partial derivative of scalarField2D with respect to the coordinate, parameter or time t coord as a new scalar field
+// The derivative is found symbolically from the expression
+func (s scalarField2D) Diff(coord string) scalarField2D {
+	if !isCoord(coord, s.coordsys) && !s.opts.isParam(coord) && coord != timeName {
+		panic(coord + " is neither a coordinate of " + s.coordsys + ", a parameter of the field nor the time t")
+	}
+	return s.withExpression(s.tree.diff(coord).String())
+}
+
+// Returns scalarField2D with its expression simplified like Simplify of scalarField
+func (s scalarField2D) Simplify() scalarField2D {
+	return s.withExpression(s.tree.simplify().String())
+}
+
+// Returns the expression of scalarField2D as canonical text, which gives the same field when used as expression
+func (s scalarField2D) String() string {
+	return s.tree.String()
+}
+
+// Returns the expression of scalarField2D as LaTeX, where theta, phi and other greek names are written as letters
+func (s scalarField2D) LaTeX() string {
+	return s.tree.latex()
+}
+
+// Returns a new two-dimensional vector field, the options opts change the default settings of the field
+func NewVectorField2D(e1, e2, coordsys string, opts ...FieldOption) vectorField2D {
+	v := vectorField2D{}
+	v.coordsys = coordsys
+	v.opts = newFieldOptions(opts)
+	check2D(coordsys)
+	for _, e := range []string{e1, e2} {
+		checkCoords(e, coordsys, v.opts)
+	}
+	return v.withExpressions(e1, e2)
+}
+
+// Returns vectorField2D with the expressions e1, e2 and their trees like withExpression of scalarField
+func (v vectorField2D) withExpressions(e1, e2 string) vectorField2D {
+	v.expressionCoord1, v.expressionCoord2 = e1, e2
+	v.treeCoord1, v.treeCoord2 = v.opts.mustParse(e1), v.opts.mustParse(e2)
+	return v
+}
+
+// Returns vectorField2D with the expression of each component simplified like Simplify of scalarField
+func (v vectorField2D) Simplify() vectorField2D {
+	return v.withExpressions(v.treeCoord1.simplify().String(), v.treeCoord2.simplify().String())
+}
+
+// Returns the components of vectorField2D as canonical text like (x, -y, 0)
+func (v vectorField2D) String() string {
+	return v.opts.vectorString(v.expressionCoord1, v.expressionCoord2)
+}
+
+// Returns vectorField2D as LaTeX, the sum of each component times the unit vector of its coordinate
+func (v vectorField2D) LaTeX() string {
+	return v.opts.vectorLatex(v.coordsys, v.expressionCoord1, v.expressionCoord2)
+}
+
+// Checks that coordsys is one of the two-dimensional coordinate systems, panics if not
+func check2D(coordsys string) {
+	if coordsys != "car2" && coordsys != "polar" {
+		panic("Two-dimensional fields use \"car2\" for cartesian coordinates or \"polar\" for polar coordinates")
+	}
+}
+
+// Returns a copy of scalarField2D where the parameter name has the value value
+func (s scalarField2D) Bind(name string, value float64) scalarField2D {
+	return s.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of scalarField2D where each parameter in values has its value
+func (s scalarField2D) BindParams(values map[string]float64) scalarField2D {
+	s.opts = s.opts.bind(values)
+	return s
+}
+
+// Returns the boundaries where a comparison in the expression of scalarField2D switches, as equations like "r=1"
+// The field may be discontinuous there and its derivatives are only correct away from them
+func (s scalarField2D) Discontinuities() []string {
+	return s.opts.discontinuities(s.expression)
+}
+
+// Returns a copy of vectorField2D where the parameter name has the value value
+func (v vectorField2D) Bind(name string, value float64) vectorField2D {
+	return v.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of vectorField2D where each parameter in values has its value
+func (v vectorField2D) BindParams(values map[string]float64) vectorField2D {
+	v.opts = v.opts.bind(values)
+	return v
+}
+
+// Returns the boundaries where a comparison in an expression of vectorField2D switches, as equations like "r=1"
+// The field may be discontinuous there and its derivatives are only correct away from them
+func (v vectorField2D) Discontinuities() []string {
+	return v.opts.discontinuities(v.expressionCoord1, v.expressionCoord2)
+}
+
+// Returns the calculation of scalarField2D at the points _1, _2 with its parameters bound
+func (s scalarField2D) fn(_1, _2 float64) float64 {
+	return s.eval([]float64{_1, _2})
+}
+
+// Returns the calculation of scalarField2D at point c with its parameters bound
+func (s scalarField2D) eval(c []float64) float64 {
+	c, coords := s.opts.withTime(c, coordNames(s.coordsys))
+	return s.opts.fnN(c, s.tree, coords)
+}
+
+// Returns the calculation of the tree of one coordinate of vectorField2D at the points _1, _2 with its parameters bound
+func (v vectorField2D) fn(_1, _2 float64, tree *node) float64 {
+	c, coords := v.opts.withTime([]float64{_1, _2}, coordNames(v.coordsys))
+	return v.opts.fnN(c, tree, coords)
+}
+
+// Returns the calculation of each coordinate of vectorField2D at point c with its parameters bound
+func (v vectorField2D) eval(c []float64) []float64 {
+	return []float64{
+		v.fn(c[0], c[1], v.treeCoord1),
+		v.fn(c[0], c[1], v.treeCoord2)}
+}
+
+// Calculates the gradient of scalarField2D
+// Returns a slice of float64 containg the calculated gradient at point c
+func (s scalarField2D) Grad(c []float64) []float64 {
+	res, err := s.TryGrad(c)
+	if err != nil {
+		panic(singularMsg(s.coordsys))
+	}
+	return res
+}
+
+// Calculates the gradient of scalarField2D like Grad
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (s scalarField2D) TryGrad(c []float64) ([]float64, error) {
+	if len(c) != 2 {
+		panic("Too many or too few points coordinates given")
+	}
+	return atSingularity(c, s.coordsys, s.opts.singularity, s.grad, func(c []float64) []float64 {
+		return gradCartesian(s.eval, c, s.coordsys)
+	})
+}
+
+// Returns the gradient of scalarField2D at point c, which is not a singular point
+func (s scalarField2D) grad(c []float64) []float64 {
+	h := 0.0001
+	switch s.coordsys {
+	case "car2":
+		x := c[0]
+		y := c[1]
+
+		return []float64{
+			(s.fn(x+h, y) - s.fn(x-h, y)) / (2 * h),
+			(s.fn(x, y+h) - s.fn(x, y-h)) / (2 * h)}
+
+	case "polar":
+		r := c[0]
+		phi := c[1]
+		return []float64{
+			(s.fn(r+h, phi) - s.fn(r-h, phi)) / (2 * h),
+			(s.fn(r, phi+h) - s.fn(r, phi-h)) / (2 * h * r)}
+	default:
+		panic("Error finding Grad, coordinates system is wrong")
+	}
+}
+
+// Calculates the laplacian of scalarField2D
+// Returns a float64 containg the calculated laplacian at point c
+func (s scalarField2D) Laplacian(c []float64) float64 {
+	res, err := s.TryLaplacian(c)
+	if err != nil {
+		panic(singularMsg(s.coordsys))
+	}
+	return res
+}
+
+// Calculates the laplacian of scalarField2D like Laplacian
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (s scalarField2D) TryLaplacian(c []float64) (float64, error) {
+	if len(c) != 2 {
+		panic("Too many or too few points coordinates given")
+	}
+	res, err := atSingularity(c, s.coordsys, s.opts.singularity, func(c []float64) []float64 {
+		return []float64{s.laplacian(c)}
+	}, func(c []float64) []float64 {
+		return []float64{laplacianCartesian(s.eval, c, s.coordsys)}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res[0], nil
+}
+
+// Returns the laplacian of scalarField2D at point c, which is not a singular point
+func (s scalarField2D) laplacian(c []float64) float64 {
+	// Second derivatives lose precision to rounding with a step as small as in Grad
+	h := 0.001
+	switch s.coordsys {
+	case "car2":
+		x := c[0]
+		y := c[1]
+		f := s.fn(x, y)
+
+		return (s.fn(x+h, y)-2*f+s.fn(x-h, y))/(h*h) +
+			(s.fn(x, y+h)-2*f+s.fn(x, y-h))/(h*h)
+
+	case "polar":
+		r := c[0]
+		phi := c[1]
+		f := s.fn(r, phi)
+
+		return (s.fn(r+h, phi)-2*f+s.fn(r-h, phi))/(h*h) +
+			(s.fn(r+h, phi)-s.fn(r-h, phi))/(2*h*r) +
+			(s.fn(r, phi+h)-2*f+s.fn(r, phi-h))/(h*h*r*r)
+	default:
+		panic("Error finding Laplacian, coordinates system is wrong")
+	}
+}
+
+// Calculates the divergence of vectorField2D
+// Returns a float64 containg the calculated divergence at point c
+func (v vectorField2D) Div(c []float64) float64 {
+	res, err := v.TryDiv(c)
+	if err != nil {
+		panic(singularMsg(v.coordsys))
+	}
+	return res
+}
+
+// Calculates the divergence of vectorField2D like Div
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (v vectorField2D) TryDiv(c []float64) (float64, error) {
+	if len(c) != 2 {
+		panic("Too many or too few points coordinates given")
+	}
+	res, err := atSingularity(c, v.coordsys, v.opts.singularity, func(c []float64) []float64 {
+		return []float64{v.div(c)}
+	}, func(c []float64) []float64 {
+		return []float64{divCartesian(v.eval, c, v.coordsys)}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res[0], nil
+}
+
+// Returns the divergence of vectorField2D at point c, which is not a singular point
+func (v vectorField2D) div(c []float64) float64 {
+	h := 0.0001
+	switch v.coordsys {
+	case "car2":
+		x := c[0]
+		y := c[1]
+
+		return ((v.fn(x+h, y, v.treeCoord1)-v.fn(x-h, y, v.treeCoord1))/(2*h) +
+			(v.fn(x, y+h, v.treeCoord2)-v.fn(x, y-h, v.treeCoord2))/(2*h))
+
+	case "polar":
+		r := c[0]
+		phi := c[1]
+		return (v.fn(r, phi, v.treeCoord1)/r +
+			(v.fn(r+h, phi, v.treeCoord1)-v.fn(r-h, phi, v.treeCoord1))/(2*h) +
+			(v.fn(r, phi+h, v.treeCoord2)-v.fn(r, phi-h, v.treeCoord2))/(2*h*r))
+	default:
+		panic("Error finding Div, coordinates system is wrong")
+	}
+}
+
+// Calculates the scalar curl of vectorField2D, that is the component of the rotation normal to the plane
+// Returns a float64 containg the calculated curl at point c
+func (v vectorField2D) Curl(c []float64) float64 {
+	res, err := v.TryCurl(c)
+	if err != nil {
+		panic(singularMsg(v.coordsys))
+	}
+	return res
+}
+
+// Calculates the scalar curl of vectorField2D like Curl
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (v vectorField2D) TryCurl(c []float64) (float64, error) {
+	if len(c) != 2 {
+		panic("Too many or too few points coordinates given")
+	}
+	res, err := atSingularity(c, v.coordsys, v.opts.singularity, func(c []float64) []float64 {
+		return []float64{v.curl(c)}
+	}, func(c []float64) []float64 {
+		return []float64{rotCartesian(v.eval, c, v.coordsys)[0]}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res[0], nil
+}
+
+// Returns the scalar curl of vectorField2D at point c, which is not a singular point
+func (v vectorField2D) curl(c []float64) float64 {
+	h := 0.0001
+	switch v.coordsys {
+	case "car2":
+		x := c[0]
+		y := c[1]
+
+		return ((v.fn(x+h, y, v.treeCoord2)-v.fn(x-h, y, v.treeCoord2))/(2*h) -
+			(v.fn(x, y+h, v.treeCoord1)-v.fn(x, y-h, v.treeCoord1))/(2*h))
+
+	case "polar":
+		r := c[0]
+		phi := c[1]
+		return (v.fn(r, phi, v.treeCoord2)/r +
+			(v.fn(r+h, phi, v.treeCoord2)-v.fn(r-h, phi, v.treeCoord2))/(2*h) -
+			(v.fn(r, phi+h, v.treeCoord1)-v.fn(r, phi-h, v.treeCoord1))/(2*h*r))
+	default:
+		panic("Error finding Curl, coordinates system is wrong")
+	}
+}
@@ -0,0 +1,118 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns true if every element in a and b differ by at most tol
+func almostEqual(a, b []float64, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNewScalarField2D(t *testing.T) {
+	var tests = []struct {
+		expression string
+		coordsys   string
+	}{
+		{"3x^2+5cos(y^2)", "car2"},
+		{"-3sin(2r^3)^5+phi", "polar"},
+	}
+	for _, v := range tests {
+		if exp := NewScalarField2D(v.expression, v.coordsys); exp.coordsys != v.coordsys || exp.expression != v.expression {
+			t.Error("Test failed: {", v.expression, v.coordsys, " } inputted, expected {", v, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestNewVectorField2D(t *testing.T) {
+	var tests = []struct {
+		expression1 string
+		expression2 string
+		coordsys    string
+	}{
+		{"72+3x^2", "cos(y)", "car2"},
+		{"3r^2", "sqrt(phi)", "polar"},
+	}
+	for _, v := range tests {
+		if exp := NewVectorField2D(v.expression1, v.expression2, v.coordsys); exp.coordsys != v.coordsys || exp.expressionCoord1 != v.expression1 || exp.expressionCoord2 != v.expression2 {
+			t.Error("Test failed: {", v.expression1, v.expression2, v.coordsys, " } inputted, expected {", v, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestGrad2D(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		s     scalarField2D
+		exp   []float64
+	}{
+		{[]float64{1, 2}, NewScalarField2D("x^2+3y", "car2"), []float64{2, 3}},
+		{[]float64{2, 1}, NewScalarField2D("r^2", "polar"), []float64{4, 0}},
+		{[]float64{2, math.Pi / 2}, NewScalarField2D("r^2*cos(phi)", "polar"), []float64{0, -2}},
+	}
+	for _, v := range tests {
+		if exp := v.s.Grad(v.point); !almostEqual(exp, v.exp, 1e-6) {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestLaplacian2D(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		s     scalarField2D
+		exp   float64
+	}{
+		{[]float64{1, 2}, NewScalarField2D("x^2+3y^2", "car2"), 8},
+		{[]float64{2, 1}, NewScalarField2D("r^2", "polar"), 4},
+		{[]float64{3, 0.5}, NewScalarField2D("r^2*cos(2phi)", "polar"), 0},
+	}
+	for _, v := range tests {
+		if exp := v.s.Laplacian(v.point); !almostEqual([]float64{exp}, []float64{v.exp}, 1e-4) {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestDiv2D(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		v     vectorField2D
+		exp   float64
+	}{
+		{[]float64{1, 1}, NewVectorField2D("x^2", "y", "car2"), 3},
+		{[]float64{2, 1}, NewVectorField2D("r", "0", "polar"), 2},
+		{[]float64{2, 1}, NewVectorField2D("0", "sin(phi)", "polar"), math.Cos(1) / 2},
+	}
+	for _, v := range tests {
+		if exp := v.v.Div(v.point); !almostEqual([]float64{exp}, []float64{v.exp}, 1e-6) {
+			t.Error("Test failed: {", v.point, v.v, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestCurl2D(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		v     vectorField2D
+		exp   float64
+	}{
+		{[]float64{1, 1}, NewVectorField2D("-y", "x", "car2"), 2},
+		{[]float64{2, 1}, NewVectorField2D("0", "r", "polar"), 2},
+		{[]float64{2, 1}, NewVectorField2D("r", "0", "polar"), 0},
+	}
+	for _, v := range tests {
+		if exp := v.v.Curl(v.point); !almostEqual([]float64{exp}, []float64{v.exp}, 1e-6) {
+			t.Error("Test failed: {", v.point, v.v, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package vcalc
+
+// An N-dimensional scalar field has a mathematical expression as string and
+// the names of its cartesian variables in the order they are given in a point
+type scalarFieldN struct {
+	expression string
+	tree       *node
+	coords     []string
+	opts       fieldOptions
+}
+
+// Returns a new N-dimensional cartesian scalar field over the variables vars
+// Of the options opts only WithFuncs and WithStrictSyntax apply, the field has no singular points and panics if given
+// parameters with WithParams, the names can instead be declared as variables
+func NewScalarFieldN(expression string, vars []string, opts ...FieldOption) scalarFieldN {
+	s := scalarFieldN{}
+	s.coords = append([]string(nil), vars...)
+	s.opts = newFieldOptions(opts)
+	if len(s.opts.params) > 0 {
+		panic("Parameters are not supported by N-dimensional fields, declare them as variables instead")
+	}
+	checkVars(expression, s.coords, s.opts)
+	return s.withExpression(expression)
+}
+
+// Returns scalarFieldN with the expression e and its tree like withExpression of scalarField
+func (s scalarFieldN) withExpression(e string) scalarFieldN {
+	s.expression = e
+	s.tree = s.opts.mustParse(e)
+	return s
+}
+
+// Checks if user has declared valid variable names and only used those in the expression, panics if not
+func checkVars(expression string, vars []string, o fieldOptions) {
+	if len(vars) == 0 {
+		panic("At least one variable name must be given")
+	}
+	tree := o.mustParse(expression)
+	declared := map[string]bool{}
+	for _, v := range vars {
+		if !identifier.MatchString(v) {
+			panic("Variable name " + v + " is invalid, names are letters optionally followed by digits")
+		}
+		if o.funcs.isFUNC(v) {
+			panic("Variable name " + v + " is the name of a function")
+		}
+		if isConst(v) {
+			panic("Variable name " + v + " is the name of a constant")
+		}
+		if declared[v] {
+			panic("Variable name " + v + " is declared more than once")
+		}
+		declared[v] = true
+	}
+	for _, v := range tree.variables() {
+		if !declared[v] && !isConst(v) {
+			panic("Undeclared variable " + v + " used in expression")
+		}
+	}
+}
+
+// Returns the partial derivative of scalarFieldN with respect to the variable coord as a new scalar field
+// The derivative is found symbolically from the expression
+func (s scalarFieldN) Diff(coord string) scalarFieldN {
+	declared := false
+	for _, v := range s.coords {
+		declared = declared || v == coord
+	}
+	if !declared {
+		panic(coord + " is not a variable of the field")
+	}
+	return s.withExpression(s.tree.diff(coord).String())
+}
+
+// Returns the boundaries where a comparison in the expression of scalarFieldN switches, as equations like "x1=1"
+// The field may be discontinuous there and its derivatives are only correct away from them
+func (s scalarFieldN) Discontinuities() []string {
+	return s.opts.discontinuities(s.expression)
+}
+
+// Returns scalarFieldN with its expression simplified like Simplify of scalarField
+func (s scalarFieldN) Simplify() scalarFieldN {
+	return s.withExpression(s.tree.simplify().String())
+}
+
+// Returns the expression of scalarFieldN as canonical text, which gives the same field when used as expression
+func (s scalarFieldN) String() string {
+	return s.tree.String()
+}
+
+// Returns the expression of scalarFieldN as LaTeX, where theta, phi and other greek names are written as letters
+func (s scalarFieldN) LaTeX() string {
+	return s.tree.latex()
+}
+
+// Returns the calculation of the expression of s at the point c
+func (s scalarFieldN) fn(c []float64) float64 {
+	return s.opts.fnN(c, s.tree, s.coords)
+}
+
+// Returns a copy of c where the coordinate i is moved by h
+func shift(c []float64, i int, h float64) []float64 {
+	p := append([]float64(nil), c...)
+	p[i] += h
+	return p
+}
+
+// Calculates the gradient of scalarFieldN
+// Returns a slice of float64 containg the calculated gradient at point c
+func (s scalarFieldN) Grad(c []float64) []float64 {
+	if len(c) != len(s.coords) {
+		panic("Too many or too few points coordinates given")
+	}
+	h := 0.0001
+	grad := make([]float64, len(c))
+	for i := range c {
+		grad[i] = (s.fn(shift(c, i, h)) - s.fn(shift(c, i, -h))) / (2 * h)
+	}
+	return grad
+}
+
+// Calculates the hessian of scalarFieldN, the matrix of all second order partial derivatives
+// Returns a slice of rows where element [i][j] is the derivative with respect to variable i and j at point c
+func (s scalarFieldN) Hessian(c []float64) [][]float64 {
+	if len(c) != len(s.coords) {
+		panic("Too many or too few points coordinates given")
+	}
+	// Second derivatives lose precision to rounding with a step as small as in Grad
+	h := 0.001
+	f := s.fn(c)
+	hessian := make([][]float64, len(c))
+	for i := range c {
+		hessian[i] = make([]float64, len(c))
+	}
+	for i := range c {
+		hessian[i][i] = (s.fn(shift(c, i, h)) - 2*f + s.fn(shift(c, i, -h))) / (h * h)
+		for j := 0; j < i; j++ {
+			hessian[i][j] = (s.fn(shift(shift(c, i, h), j, h)) - s.fn(shift(shift(c, i, h), j, -h)) -
+				s.fn(shift(shift(c, i, -h), j, h)) + s.fn(shift(shift(c, i, -h), j, -h))) / (4 * h * h)
+			hessian[j][i] = hessian[i][j]
+		}
+	}
+	return hessian
+}
+
+// Calculates the laplacian of scalarFieldN
+// Returns a float64 containg the calculated laplacian at point c
+func (s scalarFieldN) Laplacian(c []float64) float64 {
+	if len(c) != len(s.coords) {
+		panic("Too many or too few points coordinates given")
+	}
+	h := 0.001
+	f := s.fn(c)
+	var laplacian float64
+	for i := range c {
+		laplacian += (s.fn(shift(c, i, h)) - 2*f + s.fn(shift(c, i, -h))) / (h * h)
+	}
+	return laplacian
+}
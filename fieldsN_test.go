@@ -0,0 +1,119 @@
+package vcalc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewScalarFieldN(t *testing.T) {
+	var tests = []struct {
+		expression string
+		vars       []string
+	}{
+		{"a*b+c^2", []string{"a", "b", "c", "d"}},
+		{"3sin(x1)-x2^2", []string{"x1", "x2"}},
+	}
+	for _, v := range tests {
+		if exp := NewScalarFieldN(v.expression, v.vars); exp.expression != v.expression || !reflect.DeepEqual(exp.coords, v.vars) {
+			t.Error("Test failed: {", v.expression, v.vars, " } inputted, expected {", v, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestNewScalarFieldNPanics(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"no variables", func() { NewScalarFieldN("1", nil) }},
+		{"undeclared variable", func() { NewScalarFieldN("a*b", []string{"a"}) }},
+		{"function name", func() { NewScalarFieldN("sin", []string{"sin"}) }},
+		{"parameters", func() { NewScalarFieldN("k*a", []string{"a"}, WithParams("k")) }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic and got none")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
+
+func TestGetCOORDN(t *testing.T) {
+	var tests = []struct {
+		c      []float64
+		COORD  string
+		coords []string
+		exp    float64
+	}{
+		{[]float64{1, 2, 3}, "b", []string{"a", "b", "c"}, 2},
+		{[]float64{1, 2, 3}, "", []string{"a", "b", "c"}, 1},
+		{[]float64{1, 2}, "x12", []string{"x1", "x12"}, 2},
+	}
+	for _, v := range tests {
+		if exp := getCOORDN(v.c, v.COORD, v.coords); exp != v.exp {
+			t.Error("Test failed: {", v.c, v.COORD, v.coords, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestGradN(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		s     scalarFieldN
+		exp   []float64
+	}{
+		{[]float64{1, 2, 3, 4}, NewScalarFieldN("a*b+c^2", []string{"a", "b", "c", "d"}), []float64{2, 1, 6, 0}},
+		{[]float64{1, 2}, NewScalarFieldN("x+x1^2", []string{"x", "x1"}), []float64{1, 4}},
+		{[]float64{0, 1, 2, 3, 4}, NewScalarFieldN("sin(u)*v-w+3p^2+q", []string{"u", "v", "w", "p", "q"}), []float64{1, 0, -1, 18, 1}},
+	}
+	for _, v := range tests {
+		if exp := v.s.Grad(v.point); !almostEqual(exp, v.exp, 1e-6) {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestHessian(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		s     scalarFieldN
+		exp   [][]float64
+	}{
+		{[]float64{1, 2, 3, 4}, NewScalarFieldN("a*b+c^2", []string{"a", "b", "c", "d"}),
+			[][]float64{{0, 1, 0, 0}, {1, 0, 0, 0}, {0, 0, 2, 0}, {0, 0, 0, 0}}},
+		{[]float64{2, 3}, NewScalarFieldN("x^3*y^2", []string{"x", "y"}),
+			[][]float64{{108, 72}, {72, 16}}},
+	}
+	for _, v := range tests {
+		exp := v.s.Hessian(v.point)
+		if len(exp) != len(v.exp) {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+			continue
+		}
+		for i := range exp {
+			if !almostEqual(exp[i], v.exp[i], 1e-4) {
+				t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+			}
+		}
+	}
+}
+
+func TestLaplacianN(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		s     scalarFieldN
+		exp   float64
+	}{
+		{[]float64{1, 2, 3, 4}, NewScalarFieldN("a*b+c^2", []string{"a", "b", "c", "d"}), 2},
+		{[]float64{1, 2, 3, 4, 5}, NewScalarFieldN("x1^2+x2^2+x3^2+x4^2+x5^2", []string{"x1", "x2", "x3", "x4", "x5"}), 10},
+	}
+	for _, v := range tests {
+		if exp := v.s.Laplacian(v.point); !almostEqual([]float64{exp}, []float64{v.exp}, 1e-4) {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
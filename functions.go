@@ -0,0 +1,244 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// A mathematical function that can be called in an expression
+type function struct {
+	// The number of arguments, or minus the least number of arguments for functions taking any number
+	arity int
+	// Returns the value of the function given its arguments
+	eval func(args ...float64) float64
+	// Returns the partial derivative of the function with respect to each of its arguments args,
+	// nil if the function has no derivative rule
+	deriv func(args []*node) []*node
+	// True for a function taking conditions and values in pairs followed by a default value, which is the first
+	// value whose condition is not zero. It is differentiated by differentiating each value
+	piecewise bool
+}
+
+// Returns a function of one argument with the derivative d
+func unary(f func(float64) float64, d func(u *node) *node) function {
+	return function{
+		arity: 1,
+		eval: func(args ...float64) float64 {
+			return f(args[0])
+		},
+		deriv: func(args []*node) []*node {
+			return []*node{d(args[0])}
+		},
+	}
+}
+
+// Returns the tree of 1/n
+func inv(n *node) *node {
+	return op("/", num(1), n)
+}
+
+// The functions known in expressions by their names
+var functions = map[string]function{
+	"sin": unary(math.Sin, func(u *node) *node {
+		return call("cos", u)
+	}),
+	"cos": unary(math.Cos, func(u *node) *node {
+		return neg(call("sin", u))
+	}),
+	"tan": unary(math.Tan, func(u *node) *node {
+		return inv(op("^", call("cos", u), num(2)))
+	}),
+	"exp": unary(math.Exp, func(u *node) *node {
+		return call("exp", u)
+	}),
+	"sqrt": unary(math.Sqrt, func(u *node) *node {
+		return inv(op("*", num(2), call("sqrt", u)))
+	}),
+	"log": unary(math.Log, inv),
+	"ln":  unary(math.Log, inv),
+	"log10": unary(math.Log10, func(u *node) *node {
+		return inv(op("*", u, num(math.Ln10)))
+	}),
+	"abs": unary(math.Abs, func(u *node) *node {
+		return call("sign", u)
+	}),
+	"sign": unary(sign, func(u *node) *node {
+		return num(0)
+	}),
+	"asin": unary(math.Asin, func(u *node) *node {
+		return inv(call("sqrt", op("-", num(1), op("^", u, num(2)))))
+	}),
+	"acos": unary(math.Acos, func(u *node) *node {
+		return neg(inv(call("sqrt", op("-", num(1), op("^", u, num(2))))))
+	}),
+	"atan": unary(math.Atan, func(u *node) *node {
+		return inv(op("+", num(1), op("^", u, num(2))))
+	}),
+	"sinh": unary(math.Sinh, func(u *node) *node {
+		return call("cosh", u)
+	}),
+	"cosh": unary(math.Cosh, func(u *node) *node {
+		return call("sinh", u)
+	}),
+	"tanh": unary(math.Tanh, func(u *node) *node {
+		return inv(op("^", call("cosh", u), num(2)))
+	}),
+	"asinh": unary(math.Asinh, func(u *node) *node {
+		return inv(call("sqrt", op("+", op("^", u, num(2)), num(1))))
+	}),
+	"acosh": unary(math.Acosh, func(u *node) *node {
+		return inv(call("sqrt", op("-", op("^", u, num(2)), num(1))))
+	}),
+	"atanh": unary(math.Atanh, func(u *node) *node {
+		return inv(op("-", num(1), op("^", u, num(2))))
+	}),
+	"atan2": {
+		arity: 2,
+		eval: func(args ...float64) float64 {
+			return math.Atan2(args[0], args[1])
+		},
+		deriv: func(args []*node) []*node {
+			// atan2(y, x) has the partial derivatives x/(x^2+y^2) and -y/(x^2+y^2)
+			y, x := args[0], args[1]
+			r2 := op("+", op("^", x, num(2)), op("^", y, num(2)))
+			return []*node{op("/", x, r2), neg(op("/", y, r2))}
+		},
+	},
+	"pow": {
+		arity: 2,
+		eval: func(args ...float64) float64 {
+			return math.Pow(args[0], args[1])
+		},
+		deriv: func(args []*node) []*node {
+			// pow(a, b) has the partial derivatives b*a^(b-1) and a^b*ln(a)
+			a, b := args[0], args[1]
+			return []*node{
+				op("*", b, call("pow", a, op("-", b, num(1)))),
+				op("*", call("pow", a, b), call("ln", a))}
+		},
+	},
+	"besselj": {
+		arity: 2,
+		eval: func(args ...float64) float64 {
+			return besselJ(args[0], args[1])
+		},
+		deriv: func(args []*node) []*node {
+			// J_n' = (J_(n-1) - J_(n+1))/2 for the constant order n
+			n, x := args[0], args[1]
+			return []*node{num(0), op("/", op("-", call("besselj", op("-", n, num(1)), x), call("besselj", op("+", n, num(1)), x)), num(2))}
+		},
+	},
+	"bessely": {
+		arity: 2,
+		eval: func(args ...float64) float64 {
+			return besselY(args[0], args[1])
+		},
+		deriv: func(args []*node) []*node {
+			// Y_n' = (Y_(n-1) - Y_(n+1))/2 for the constant order n
+			n, x := args[0], args[1]
+			return []*node{num(0), op("/", op("-", call("bessely", op("-", n, num(1)), x), call("bessely", op("+", n, num(1)), x)), num(2))}
+		},
+	},
+	"legendre": {
+		arity: 3,
+		eval: func(args ...float64) float64 {
+			return legendre(args[0], args[1], args[2])
+		},
+		deriv: legendreDeriv,
+	},
+	"ylm": {
+		arity: 4,
+		eval: func(args ...float64) float64 {
+			return ylm(args[0], args[1], args[2], args[3])
+		},
+		deriv: ylmDeriv,
+	},
+	"erf": unary(math.Erf, func(u *node) *node {
+		return op("*", num(2/math.SqrtPi), call("exp", neg(op("^", u, num(2)))))
+	}),
+	"erfc": unary(math.Erfc, func(u *node) *node {
+		return op("*", num(-2/math.SqrtPi), call("exp", neg(op("^", u, num(2)))))
+	}),
+	// The derivative of gamma needs the digamma function, which is not in the math package
+	"gamma": {
+		arity: 1,
+		eval: func(args ...float64) float64 {
+			return math.Gamma(args[0])
+		},
+	},
+	"min": {
+		arity: -2,
+		eval: func(args ...float64) float64 {
+			res := args[0]
+			for _, v := range args[1:] {
+				res = math.Min(res, v)
+			}
+			return res
+		},
+		deriv: func(args []*node) []*node {
+			return extremumDeriv(args, -1)
+		},
+	},
+	"max": {
+		arity: -2,
+		eval: func(args ...float64) float64 {
+			res := args[0]
+			for _, v := range args[1:] {
+				res = math.Max(res, v)
+			}
+			return res
+		},
+		deriv: func(args []*node) []*node {
+			return extremumDeriv(args, 1)
+		},
+	},
+	"if": {
+		arity:     3,
+		eval:      choose,
+		piecewise: true,
+	},
+	"piecewise": {
+		arity:     -3,
+		eval:      choose,
+		piecewise: true,
+	},
+}
+
+// Returns the first value in args whose condition is not zero, or the last value if every condition is zero,
+// where args are conditions and values in pairs followed by the default value
+func choose(args ...float64) float64 {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] != 0 {
+			return args[i+1]
+		}
+	}
+	return args[len(args)-1]
+}
+
+// Returns -1, 0 or 1 for negative, zero and positive x
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return x
+	}
+}
+
+// Returns the partial derivatives of min (s = -1) or max (s = 1) of args
+// The derivative with respect to an argument is 1 where it is the extremum and 0 elsewhere, written with
+// the function sign as the product over the other arguments of (1 + s*sign(arg - other))/2
+func extremumDeriv(args []*node, s float64) []*node {
+	partials := make([]*node, len(args))
+	for i, arg := range args {
+		partials[i] = num(1)
+		for j, other := range args {
+			if i != j {
+				step := op("/", op("+", num(1), op("*", num(s), call("sign", op("-", arg, other)))), num(2))
+				partials[i] = op("*", partials[i], step)
+			}
+		}
+	}
+	return partials
+}
@@ -0,0 +1,211 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// A grid is a regular cartesian grid of n[0]*n[1]*n[2] points in the box from the corner lower to the corner upper
+// A periodic grid repeats itself along each axis, so its points do not include the upper corner, while the points
+// of a grid which is not periodic include both corners
+type grid struct {
+	lower, upper []float64
+	n            []int
+	periodic     bool
+}
+
+// A scalar grid holds the value of a scalar field at each point of a grid
+type scalarGrid struct {
+	grid
+	values []float64
+}
+
+// A vector grid holds the cartesian components of a vector field at each point of a grid
+type vectorGrid struct {
+	grid
+	values [][]float64
+}
+
+// Returns the grid from lower to upper with n[i] points along the axis i, panics if the sizes are wrong
+func newGrid(lower, upper []float64, n []int, periodic bool) grid {
+	checkCenter(lower)
+	checkCenter(upper)
+	if len(n) != 3 {
+		panic("A grid needs the number of points along each of the three axes")
+	}
+	for i := range n {
+		if n[i] < 3 {
+			panic("A grid needs at least three points along each axis")
+		}
+		if upper[i] <= lower[i] {
+			panic("The upper corner of a grid must be above the lower corner along each axis")
+		}
+	}
+	return grid{lower, upper, n, periodic}
+}
+
+// Returns a new vector grid of the measured cartesian components values[index] at the points of the grid from the
+// corner lower to upper with n[i] points along the axis i, where the point i, j, k has the index (i*n[1]+j)*n[2]+k
+func NewVectorGrid(lower, upper []float64, n []int, periodic bool, values [][]float64) vectorGrid {
+	g := newGrid(lower, upper, n, periodic)
+	if len(values) != g.size() {
+		panic("A grid needs one value for each of its points")
+	}
+	for _, v := range values {
+		checkCenter(v)
+	}
+	return vectorGrid{g, values}
+}
+
+// Returns the vector grid of the cartesian components of vectorField at the points of the grid from the cartesian
+// corner lower to upper with n[i] points along the axis i, like NewVectorGrid
+func (v vectorField) Sample(lower, upper []float64, n []int, periodic bool) vectorGrid {
+	g := newGrid(lower, upper, n, periodic)
+	values := make([][]float64, g.size())
+	for i := range values {
+		c := fromCartesian(g.point(i), v.coordsys)
+		values[i] = vecToCartesian(v.eval(c), c, v.coordsys)
+	}
+	return vectorGrid{g, values}
+}
+
+// Returns the number of points of grid
+func (g grid) size() int {
+	return g.n[0] * g.n[1] * g.n[2]
+}
+
+// Returns the index of the point i, j, k of grid
+func (g grid) index(i, j, k int) int {
+	return (i*g.n[1]+j)*g.n[2] + k
+}
+
+// Returns the position of the point with index along each axis
+func (g grid) position(index int) []int {
+	return []int{index / (g.n[1] * g.n[2]), index / g.n[2] % g.n[1], index % g.n[2]}
+}
+
+// Returns the distance between the points of grid along the axis a
+func (g grid) spacing(a int) float64 {
+	if g.periodic {
+		return (g.upper[a] - g.lower[a]) / float64(g.n[a])
+	}
+	return (g.upper[a] - g.lower[a]) / float64(g.n[a]-1)
+}
+
+// Returns the cartesian coordinates of the point with index
+func (g grid) point(index int) []float64 {
+	res := make([]float64, 3)
+	for a, i := range g.position(index) {
+		res[a] = g.lower[a] + float64(i)*g.spacing(a)
+	}
+	return res
+}
+
+// Returns the cartesian coordinates of the point i, j, k of grid
+func (g grid) Point(i, j, k int) []float64 {
+	return g.point(g.index(i, j, k))
+}
+
+// Returns the derivative along the axis a of the values f of the points of grid at the point with index, with the
+// central difference, which wraps around a periodic grid and is one-sided at the boundary of any other grid
+func (g grid) diff(f func(index int) float64, index, a int) float64 {
+	p := g.position(index)
+	at := func(shift int) float64 {
+		q := append([]int(nil), p...)
+		q[a] = (q[a] + shift + g.n[a]) % g.n[a]
+		return f(g.index(q[0], q[1], q[2]))
+	}
+	h := g.spacing(a)
+	switch {
+	case g.periodic || p[a] > 0 && p[a] < g.n[a]-1:
+		return (at(1) - at(-1)) / (2 * h)
+	case p[a] == 0:
+		return (-3*at(0) + 4*at(1) - at(2)) / (2 * h)
+	default:
+		return (3*at(0) - 4*at(-1) + at(-2)) / (2 * h)
+	}
+}
+
+// Returns the value at the point i, j, k of scalarGrid
+func (s scalarGrid) At(i, j, k int) float64 {
+	return s.values[s.index(i, j, k)]
+}
+
+// Returns the components at the point i, j, k of vectorGrid
+func (v vectorGrid) At(i, j, k int) []float64 {
+	return v.values[v.index(i, j, k)]
+}
+
+// Returns the component c of vectorGrid as a function of the index of a point
+func (v vectorGrid) component(c int) func(int) float64 {
+	return func(index int) float64 {
+		return v.values[index][c]
+	}
+}
+
+// Calculates the gradient of scalarGrid at each point with central differences, like Div of vectorGrid
+func (s scalarGrid) Grad() vectorGrid {
+	values := make([][]float64, s.size())
+	f := func(index int) float64 {
+		return s.values[index]
+	}
+	for i := range values {
+		values[i] = []float64{s.diff(f, i, 0), s.diff(f, i, 1), s.diff(f, i, 2)}
+	}
+	return vectorGrid{s.grid, values}
+}
+
+// Calculates the divergence of vectorGrid at each point with central differences, which are one-sided at the
+// boundary of a grid which is not periodic
+func (v vectorGrid) Div() scalarGrid {
+	values := make([]float64, v.size())
+	for i := range values {
+		values[i] = v.diff(v.component(0), i, 0) + v.diff(v.component(1), i, 1) + v.diff(v.component(2), i, 2)
+	}
+	return scalarGrid{v.grid, values}
+}
+
+// Calculates the rotation of vectorGrid at each point with central differences, like Div
+func (v vectorGrid) Rot() vectorGrid {
+	values := make([][]float64, v.size())
+	for i := range values {
+		d := func(c, a int) float64 {
+			return v.diff(v.component(c), i, a)
+		}
+		values[i] = []float64{d(2, 1) - d(1, 2), d(0, 2) - d(2, 0), d(1, 0) - d(0, 1)}
+	}
+	return vectorGrid{v.grid, values}
+}
+
+// Returns the components of vectorGrid at the cartesian point p by trilinear interpolation between the points
+// around it, where p must be inside the box of the grid
+func (v vectorGrid) interpolate(p []float64) []float64 {
+	base := make([]int, 3)
+	frac := make([]float64, 3)
+	for a := range p {
+		x := (p[a] - v.lower[a]) / v.spacing(a)
+		last := v.n[a] - 2
+		if v.periodic {
+			last = v.n[a] - 1
+		}
+		base[a] = int(math.Min(math.Max(math.Floor(x), 0), float64(last)))
+		frac[a] = x - float64(base[a])
+	}
+	res := make([]float64, 3)
+	for corner := 0; corner < 8; corner++ {
+		w := 1.0
+		q := make([]int, 3)
+		for a := range q {
+			if corner>>a&1 == 1 {
+				q[a] = (base[a] + 1) % v.n[a]
+				w *= frac[a]
+			} else {
+				q[a] = base[a]
+				w *= 1 - frac[a]
+			}
+		}
+		for c, value := range v.At(q[0], q[1], q[2]) {
+			res[c] += w * value
+		}
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGrid(t *testing.T) {
+	F := NewVectorField("x^2", "y*z", "z", "car").Sample([]float64{0, 0, 0}, []float64{1, 2, 3}, []int{3, 5, 4}, false)
+	if got, exp := F.Point(1, 2, 3), []float64{0.5, 1, 3}; !almostEqual(got, exp, 1e-12) {
+		t.Error("Test failed: { 1, 2, 3 } inputted, expected {", exp, "} and got {", got, "}")
+	}
+	if got, exp := F.At(1, 2, 3), []float64{0.25, 3, 3}; !almostEqual(got, exp, 1e-12) {
+		t.Error("Test failed: { 1, 2, 3 } inputted, expected {", exp, "} and got {", got, "}")
+	}
+	// The differences are exact for fields of second order, also at the boundary
+	div, rot := F.Div(), F.Rot()
+	for _, p := range [][]int{{0, 0, 0}, {1, 2, 3}, {2, 4, 1}} {
+		c := F.Point(p[0], p[1], p[2])
+		if got, exp := div.At(p[0], p[1], p[2]), 2*c[0]+c[2]+1; math.Abs(got-exp) > 1e-12 {
+			t.Error("Test failed: {", p, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+		if got, exp := rot.At(p[0], p[1], p[2]), []float64{-c[1], 0, 0}; !almostEqual(got, exp, 1e-12) {
+			t.Error("Test failed: {", p, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+	// A periodic grid does not include the upper corner and its differences wrap around
+	P := NewVectorField("sin(x)", "0", "0", "car").Sample([]float64{0, 0, 0}, []float64{2 * math.Pi, 1, 1}, []int{8, 3, 3}, true)
+	h := math.Pi / 4
+	if got, exp := P.Div().At(0, 1, 1), math.Sin(h)/h; math.Abs(got-exp) > 1e-12 || P.Point(7, 0, 0)[0] != 7*h {
+		t.Error("Test failed: { periodic } inputted, expected {", exp, "} and got {", got, P.Point(7, 0, 0), "}")
+	}
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"too few points", func() {
+			NewVectorField("x", "y", "z", "car").Sample([]float64{0, 0, 0}, []float64{1, 1, 1}, []int{2, 3, 3}, false)
+		}},
+		{"empty box", func() {
+			NewVectorGrid([]float64{0, 0, 0}, []float64{1, 0, 1}, []int{3, 3, 3}, false, make([][]float64, 27))
+		}},
+		{"too few values", func() {
+			NewVectorGrid([]float64{0, 0, 0}, []float64{1, 1, 1}, []int{3, 3, 3}, false, make([][]float64, 26))
+		}},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
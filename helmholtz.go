@@ -0,0 +1,298 @@
+package vcalc
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// Returns the discrete Fourier transform of a, or the inverse transform divided by the length of a if inverse is
+// true, with the radix-2 fast Fourier transform when the length is a power of two and the direct sum otherwise
+func fft(a []complex128, inverse bool) []complex128 {
+	n := len(a)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	res := make([]complex128, n)
+	if n&(n-1) == 0 {
+		copy(res, a)
+		// Reorder by the reversed bits of the index, then combine the transforms of length 2, 4, ... n
+		for i, j := 1, 0; i < n; i++ {
+			bit := n >> 1
+			for ; j&bit != 0; bit >>= 1 {
+				j ^= bit
+			}
+			j ^= bit
+			if i < j {
+				res[i], res[j] = res[j], res[i]
+			}
+		}
+		for length := 2; length <= n; length <<= 1 {
+			w := cmplx.Rect(1, sign*2*math.Pi/float64(length))
+			for start := 0; start < n; start += length {
+				wk := complex(1, 0)
+				for k := 0; k < length/2; k++ {
+					u, v := res[start+k], res[start+k+length/2]*wk
+					res[start+k], res[start+k+length/2] = u+v, u-v
+					wk *= w
+				}
+			}
+		}
+	} else {
+		for k := range res {
+			for j, x := range a {
+				res[k] += x * cmplx.Rect(1, sign*2*math.Pi*float64(j*k)/float64(n))
+			}
+		}
+	}
+	if inverse {
+		for i := range res {
+			res[i] /= complex(float64(n), 0)
+		}
+	}
+	return res
+}
+
+// Returns the three-dimensional Fourier transform of the values at the points of grid, or the inverse transform,
+// calculated as the transforms of the lines of points along each axis in turn
+func (g grid) fft(values []complex128, inverse bool) []complex128 {
+	res := append([]complex128(nil), values...)
+	for a := 0; a < 3; a++ {
+		for index := range res {
+			if g.position(index)[a] != 0 {
+				continue
+			}
+			// The line along the axis a through the point index at its start
+			line := make([]int, g.n[a])
+			for i := range line {
+				p := g.position(index)
+				p[a] = i
+				line[i] = g.index(p[0], p[1], p[2])
+			}
+			in := make([]complex128, len(line))
+			for i, l := range line {
+				in[i] = res[l]
+			}
+			for i, x := range fft(in, inverse) {
+				res[line[i]] = x
+			}
+		}
+	}
+	return res
+}
+
+// ErrNotConverged is returned by TryHelmholtz when the iterative solution of the Poisson equation of a grid which is
+// not periodic does not converge
+var ErrNotConverged = errors.New("vcalc: poisson equation did not converge")
+
+// Splits vectorGrid into the curl-free part grad(phi) and the divergence-free part rot(A), returning both parts,
+// the scalar potential phi and the vector potential A, where vectorGrid is the sum of the two parts
+// A periodic grid is split with the fast Fourier transform, where the wave numbers are those of the central
+// differences, so Div of the divergence-free part and Rot of the curl-free part are zero up to rounding. The mean
+// of the field and the waves the central differences do not see are in the divergence-free part but not in A
+// Any other grid is split by solving the Poisson equation laplacian(phi) = div(F) with phi 0 on the boundary, where
+// the laplacian is the compact difference of the point and its six neighbours, so the curl-free part is normal to
+// the boundary, and A is found with the homotopy formula about the center of the box, like VectorPotential of
+// vectorField. Div of the divergence-free part is zero inside the grid up to the error of the differences, which is
+// of second order in the spacing, and Rot of A equals the divergence-free part up to the error of the
+// interpolation. Panics if the Poisson equation does not converge
+func (v vectorGrid) Helmholtz() (vectorGrid, vectorGrid, scalarGrid, vectorGrid) {
+	curlFree, divFree, phi, A, err := v.TryHelmholtz()
+	if err != nil {
+		panic("The Poisson equation of the grid did not converge within one iteration per point")
+	}
+	return curlFree, divFree, phi, A
+}
+
+// Splits vectorGrid like Helmholtz
+// Returns ErrNotConverged instead of panicking when the Poisson equation of a grid which is not periodic does not
+// converge
+func (v vectorGrid) TryHelmholtz() (vectorGrid, vectorGrid, scalarGrid, vectorGrid, error) {
+	if v.periodic {
+		curlFree, divFree, phi, A := v.helmholtzPeriodic()
+		return curlFree, divFree, phi, A, nil
+	}
+	phi, err := v.poisson(v.Div().values)
+	if err != nil {
+		return vectorGrid{}, vectorGrid{}, scalarGrid{}, vectorGrid{}, err
+	}
+	curlFree := phi.Grad()
+	divFree := vectorGrid{v.grid, make([][]float64, v.size())}
+	for i := range divFree.values {
+		divFree.values[i] = make([]float64, 3)
+		for c := range divFree.values[i] {
+			divFree.values[i][c] = v.values[i][c] - curlFree.values[i][c]
+		}
+	}
+	return curlFree, divFree, phi, divFree.homotopy(), nil
+}
+
+// Returns the parts and potentials of the periodic vectorGrid like Helmholtz
+func (v vectorGrid) helmholtzPeriodic() (vectorGrid, vectorGrid, scalarGrid, vectorGrid) {
+	F := make([][]complex128, 3)
+	for c := range F {
+		values := make([]complex128, v.size())
+		for i := range values {
+			values[i] = complex(v.values[i][c], 0)
+		}
+		F[c] = v.fft(values, false)
+	}
+	L, T, A := make([][]complex128, 3), make([][]complex128, 3), make([][]complex128, 3)
+	for c := range L {
+		L[c], T[c], A[c] = make([]complex128, v.size()), make([]complex128, v.size()), make([]complex128, v.size())
+	}
+	phi := make([]complex128, v.size())
+	for index := range phi {
+		// The central difference of a wave exp(i*k*x) is i*sin(k*h)/h times the wave, so k is sin(k*h)/h
+		k := make([]float64, 3)
+		var kk, sines float64
+		for a, m := range v.position(index) {
+			sin := math.Sin(2 * math.Pi * float64(m) / float64(v.n[a]))
+			k[a] = sin / v.spacing(a)
+			kk += k[a] * k[a]
+			sines += sin * sin
+		}
+		f := []complex128{F[0][index], F[1][index], F[2][index]}
+		// The mean and the waves whose central differences are zero along every axis
+		if sines < 1e-20 {
+			for c := range T {
+				T[c][index] = f[c]
+			}
+			continue
+		}
+		kf := complex(k[0], 0)*f[0] + complex(k[1], 0)*f[1] + complex(k[2], 0)*f[2]
+		phi[index] = -1i * kf / complex(kk, 0)
+		cross := []complex128{
+			complex(k[1], 0)*f[2] - complex(k[2], 0)*f[1],
+			complex(k[2], 0)*f[0] - complex(k[0], 0)*f[2],
+			complex(k[0], 0)*f[1] - complex(k[1], 0)*f[0]}
+		for c := range L {
+			L[c][index] = complex(k[c]/kk, 0) * kf
+			T[c][index] = f[c] - L[c][index]
+			A[c][index] = 1i * cross[c] / complex(kk, 0)
+		}
+	}
+	vectors := func(parts [][]complex128) vectorGrid {
+		res := vectorGrid{v.grid, make([][]float64, v.size())}
+		for i := range res.values {
+			res.values[i] = make([]float64, 3)
+		}
+		for c, part := range parts {
+			for i, x := range v.fft(part, true) {
+				res.values[i][c] = real(x)
+			}
+		}
+		return res
+	}
+	potential := scalarGrid{v.grid, make([]float64, v.size())}
+	for i, x := range v.fft(phi, true) {
+		potential.values[i] = real(x)
+	}
+	return vectors(L), vectors(T), potential, vectors(A)
+}
+
+// Returns the solution phi of the Poisson equation laplacian(phi) = rhs at the points inside the grid of vectorGrid
+// with phi 0 on its boundary, where the laplacian at a point is the second central difference along each axis, so
+// it only uses the point and its six neighbours. The equations are symmetric and solved with the conjugate gradient
+// method, which returns ErrNotConverged if the residual is not reduced by a factor of 1e12 within one iteration
+// per point of the grid
+func (v vectorGrid) poisson(rhs []float64) (scalarGrid, error) {
+	inside := func(index int) bool {
+		for a, i := range v.position(index) {
+			if i == 0 || i == v.n[a]-1 {
+				return false
+			}
+		}
+		return true
+	}
+	// apply returns the negative laplacian of phi inside the grid and 0 on the boundary, where phi is 0
+	apply := func(phi []float64) []float64 {
+		res := make([]float64, len(phi))
+		for index := range res {
+			if !inside(index) {
+				continue
+			}
+			p := v.position(index)
+			for a := range p {
+				h := v.spacing(a)
+				p[a]++
+				next := phi[v.index(p[0], p[1], p[2])]
+				p[a] -= 2
+				prev := phi[v.index(p[0], p[1], p[2])]
+				p[a]++
+				res[index] += (2*phi[index] - next - prev) / (h * h)
+			}
+		}
+		return res
+	}
+	dot := func(a, b []float64) float64 {
+		var res float64
+		for i := range a {
+			res += a[i] * b[i]
+		}
+		return res
+	}
+	n := v.size()
+	phi, r := make([]float64, n), make([]float64, n)
+	for i := range r {
+		if inside(i) {
+			r[i] = -rhs[i]
+		}
+	}
+	p := append([]float64(nil), r...)
+	rr := dot(r, r)
+	tol := 1e-12 * math.Sqrt(rr)
+	for iteration := 0; iteration < n; iteration++ {
+		if math.Sqrt(rr) <= tol {
+			return scalarGrid{v.grid, phi}, nil
+		}
+		q := apply(p)
+		alpha := rr / dot(p, q)
+		for i := range phi {
+			phi[i] += alpha * p[i]
+			r[i] -= alpha * q[i]
+		}
+		next := dot(r, r)
+		for i := range p {
+			p[i] = r[i] + next/rr*p[i]
+		}
+		rr = next
+	}
+	if math.Sqrt(rr) > tol {
+		return scalarGrid{}, ErrNotConverged
+	}
+	return scalarGrid{v.grid, phi}, nil
+}
+
+// Returns a vector potential of vectorGrid in the Poincaré gauge about the center of its box, calculated at each
+// point with the homotopy formula of VectorPotential and the components interpolated between the points
+func (v vectorGrid) homotopy() vectorGrid {
+	center := make([]float64, 3)
+	steps := 0
+	for a := range center {
+		center[a] = (v.lower[a] + v.upper[a]) / 2
+		steps = int(math.Max(float64(steps), float64(v.n[a])))
+	}
+	nodes, weights, _ := kronrodRule()
+	res := vectorGrid{v.grid, make([][]float64, v.size())}
+	for index := range res.values {
+		p := v.point(index)
+		d := []float64{p[0] - center[0], p[1] - center[1], p[2] - center[2]}
+		A := make([]float64, 3)
+		// The 15-point Kronrod rule on each of steps pieces of the line, which are about as long as the spacing
+		for s := 0; s < steps; s++ {
+			mid, half := (float64(s)+0.5)/float64(steps), 0.5/float64(steps)
+			for i, x := range nodes {
+				t := mid + half*x
+				b := v.interpolate([]float64{center[0] + t*d[0], center[1] + t*d[1], center[2] + t*d[2]})
+				cross := []float64{b[1]*d[2] - b[2]*d[1], b[2]*d[0] - b[0]*d[2], b[0]*d[1] - b[1]*d[0]}
+				for c := range A {
+					A[c] += weights[i] * half * t * cross[c]
+				}
+			}
+		}
+		res.values[index] = A
+	}
+	return res
+}
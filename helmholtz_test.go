@@ -0,0 +1,148 @@
+package vcalc
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	// The fast transform of length 8 and the direct sum of length 6 agree with the definition of the transform
+	for _, n := range []int{8, 6} {
+		a := make([]complex128, n)
+		for i := range a {
+			a[i] = complex(float64(i*i)-2, math.Sin(float64(i)))
+		}
+		exp := make([]complex128, n)
+		for k := range exp {
+			for j, x := range a {
+				exp[k] += x * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+			}
+		}
+		if got := fft(a, false); !complexEqual(got, exp, 1e-9) {
+			t.Error("Test failed: {", a, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+		if got := fft(fft(a, false), true); !complexEqual(got, a, 1e-12) {
+			t.Error("Test failed: {", a, " } inputted, expected {", a, "} and got {", got, "}")
+		}
+	}
+}
+
+// Returns the largest difference of the components of a and b at the points at least margin from the boundary
+func gridDistance(a, b vectorGrid, margin int) float64 {
+	var res float64
+	for index, v := range a.values {
+		p := a.position(index)
+		if !a.periodic && (p[0] < margin || p[1] < margin || p[2] < margin ||
+			p[0] >= a.n[0]-margin || p[1] >= a.n[1]-margin || p[2] >= a.n[2]-margin) {
+			continue
+		}
+		for c := range v {
+			res = math.Max(res, math.Abs(v[c]-b.values[index][c]))
+		}
+	}
+	return res
+}
+
+func TestHelmholtzPeriodic(t *testing.T) {
+	// The gradient of sin(x)*cos(y) plus a divergence-free field, where the spacing along x and y is the same so that
+	// the waves of the central differences have the direction of the waves of the gradient
+	lower, upper, n := []float64{0, 0, 0}, []float64{2 * math.Pi, 2 * math.Pi, 2 * math.Pi}, []int{16, 16, 8}
+	grad := NewVectorField("cos(x)*cos(y)", "-sin(x)*sin(y)", "0", "car").Sample(lower, upper, n, true)
+	solenoidal := NewVectorField("sin(y)", "sin(z)", "cos(x)", "car").Sample(lower, upper, n, true)
+	F := NewVectorField("cos(x)*cos(y)+sin(y)", "-sin(x)*sin(y)+sin(z)", "cos(x)", "car").Sample(lower, upper, n, true)
+	curlFree, divFree, phi, A := F.Helmholtz()
+	zero := vectorGrid{F.grid, make([][]float64, F.size())}
+	for i := range zero.values {
+		zero.values[i] = make([]float64, 3)
+	}
+	var tests = []struct {
+		name string
+		got  vectorGrid
+		exp  vectorGrid
+	}{
+		{"curl-free part", curlFree, grad},
+		{"divergence-free part", divFree, solenoidal},
+		{"gradient of phi", phi.Grad(), curlFree},
+		{"rotation of A", A.Rot(), divFree},
+		{"rotation of the curl-free part", curlFree.Rot(), zero},
+		{"divergence of the divergence-free part", vectorGrid{F.grid, divergences(divFree)}, zero},
+	}
+	for _, v := range tests {
+		if d := gridDistance(v.got, v.exp, 0); d > 1e-10 {
+			t.Error("Test failed: {", v.name, " } inputted, expected a difference of {", 0, "} and got {", d, "}")
+		}
+	}
+}
+
+func TestHelmholtz(t *testing.T) {
+	lower, upper, n := []float64{-1, -1, -1}, []float64{1, 1, 1}, []int{13, 11, 13}
+	F := NewVectorField("2*x-y+sin(3*y*z)", "z+x+exp(x)", "y*x^2", "car").Sample(lower, upper, n, false)
+	curlFree, divFree, phi, A := F.Helmholtz()
+	sum := vectorGrid{F.grid, make([][]float64, F.size())}
+	zero := vectorGrid{F.grid, make([][]float64, F.size())}
+	for i := range sum.values {
+		sum.values[i] = []float64{curlFree.values[i][0] + divFree.values[i][0], curlFree.values[i][1] + divFree.values[i][1], curlFree.values[i][2] + divFree.values[i][2]}
+		zero.values[i] = make([]float64, 3)
+	}
+	var tests = []struct {
+		name   string
+		got    vectorGrid
+		exp    vectorGrid
+		margin int
+		tol    float64
+	}{
+		{"sum of the parts", sum, F, 0, 1e-12},
+		{"gradient of phi", phi.Grad(), curlFree, 0, 1e-12},
+		// Div of Grad differs from the compact laplacian by the error of the differences, largest near the boundary
+		{"divergence of the divergence-free part", vectorGrid{F.grid, divergences(divFree)}, zero, 3, 0.1},
+		{"rotation of the curl-free part", curlFree.Rot(), zero, 2, 1e-9},
+		// The interpolation error of A is largest near the boundary of the box
+		{"rotation of A", A.Rot(), divFree, 3, 0.03},
+	}
+	for _, v := range tests {
+		if d := gridDistance(v.got, v.exp, v.margin); d > v.tol {
+			t.Error("Test failed: {", v.name, " } inputted, expected a difference of {", 0, "} and got {", d, "}")
+		}
+	}
+	// phi is 0 on the boundary
+	if got := phi.At(0, 5, 7) + phi.At(12, 3, 2) + phi.At(4, 10, 0); got != 0 {
+		t.Error("Test failed: { boundary } inputted, expected {", 0, "} and got {", got, "}")
+	}
+	// A field without divergence has no curl-free part
+	_, _, phi, _ = NewVectorField("y", "z", "x", "car").Sample(lower, upper, n, false).Helmholtz()
+	for i, got := range phi.values {
+		if got != 0 {
+			t.Error("Test failed: {", phi.point(i), " } inputted, expected {", 0, "} and got {", got, "}")
+		}
+	}
+}
+
+// Returns the divergence of v at each point as the first component of a vector
+func divergences(v vectorGrid) [][]float64 {
+	res := make([][]float64, v.size())
+	for i, d := range v.Div().values {
+		res[i] = []float64{d, 0, 0}
+	}
+	return res
+}
+
+func TestTryHelmholtz(t *testing.T) {
+	// The gradient of a potential which is 0 on the boundary is the curl-free part, up to the error of the
+	// differences which is of second order in the spacing, so it is about a quarter for half the spacing
+	lower, upper := []float64{-1, -1, -1}, []float64{1, 1, 1}
+	F := NewVectorField("pi*cos(pi*x)*sin(pi*y)*sin(pi*z)", "pi*sin(pi*x)*cos(pi*y)*sin(pi*z)", "pi*sin(pi*x)*sin(pi*y)*cos(pi*z)", "car")
+	var diffs []float64
+	for _, n := range [][]int{{13, 11, 13}, {25, 21, 25}} {
+		grid := F.Sample(lower, upper, n, false)
+		curlFree, _, _, _, err := grid.TryHelmholtz()
+		if err != nil {
+			t.Error("Test failed: {", n, " } inputted, expected {", nil, "} and got {", err, "}")
+			return
+		}
+		diffs = append(diffs, gridDistance(curlFree, grid, 0))
+	}
+	if diffs[0] > 0.3 || diffs[1] > diffs[0]/3 {
+		t.Error("Test failed: { spacing } inputted, expected second order convergence and got {", diffs, "}")
+	}
+}
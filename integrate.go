@@ -0,0 +1,201 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// Settings of a numerical integral that are given as options to the methods calculating integrals
+type integralOptions struct {
+	tol      float64
+	maxDepth int
+	maxEvals int
+	samples  int
+}
+
+// An IntegralOption changes a setting of a numerical integral, like the tolerance of its error
+type IntegralOption func(*integralOptions)
+
+// Returns the settings given by opts, every setting not given keeps its default value
+func newIntegralOptions(opts []IntegralOption) integralOptions {
+	o := integralOptions{tol: 1e-8, maxDepth: 20, maxEvals: 100000, samples: 100000}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Returns an option setting the error an integral is calculated to, which is absolute for integrals smaller than one
+// and relative to the integral for larger ones, the default is 1e-8
+// A volume integral calculated with Monte Carlo integration does not reach it, see TryIntegrate
+func WithTolerance(tol float64) IntegralOption {
+	return func(o *integralOptions) {
+		if tol <= 0 {
+			panic("The tolerance of an integral must be larger than zero")
+		}
+		o.tol = tol
+	}
+}
+
+// Returns an option setting how many times an interval of an integral is at most halved to reach the tolerance,
+// the default is 20
+func WithMaxDepth(depth int) IntegralOption {
+	return func(o *integralOptions) {
+		if depth < 0 {
+			panic("The depth of an integral can not be negative")
+		}
+		o.maxDepth = depth
+	}
+}
+
+// Returns an option setting how many times a volume integral evaluates its integrand with nested quadrature before
+// it is calculated with Monte Carlo integration instead, the default is 100000 and 0 always uses Monte Carlo
+func WithMaxEvals(n int) IntegralOption {
+	return func(o *integralOptions) {
+		if n < 0 {
+			panic("The number of evaluations of an integral can not be negative")
+		}
+		o.maxEvals = n
+	}
+}
+
+// Returns an option setting how many random points a volume integral calculated with Monte Carlo integration
+// evaluates its integrand at, the default is 100000
+func WithSamples(n int) IntegralOption {
+	return func(o *integralOptions) {
+		if n < 2 {
+			panic("Monte Carlo integration needs at least two samples")
+		}
+		o.samples = n
+	}
+}
+
+// The nodes of the 15-point Kronrod rule on [-1, 1], the nodes with odd index are also the nodes of the 7-point Gauss rule
+var kronrodNodes = []float64{
+	0.991455371120812639206854697526329, 0.949107912342758524526189684047851,
+	0.864864423359769072789712788640926, 0.741531185599394439863864773280788,
+	0.586087235467691130294144845693013, 0.405845151377397166906606412076961,
+	0.207784955007898467600689403773245, 0,
+}
+
+// The weights of the 15-point Kronrod rule for each node in kronrodNodes
+var kronrodWeights = []float64{
+	0.022935322010529224963732008058970, 0.063092092629978553290700663189204,
+	0.104790010322250183839876322541518, 0.140653259715525918745189590510238,
+	0.169004726639267902826583426598550, 0.190350578064785409913256402421014,
+	0.204432940075298892414161999234649, 0.209482141084727828012999174891714,
+}
+
+// The weights of the 7-point Gauss rule for the nodes with odd index in kronrodNodes
+var gaussWeights = []float64{
+	0.129484966168869693270611432679082, 0.279705391489276667901467771423780,
+	0.381830050505118944950369775488975, 0.417959183673469387755102040816327,
+}
+
+// Returns the 15-point Kronrod rule of f on [a, b] and the difference to the 7-point Gauss rule as error estimate
+func gaussKronrod(f func(float64) float64, a, b float64) (float64, float64) {
+	center, half := (a+b)/2, (b-a)/2
+	var kronrod, gauss float64
+	for i, x := range kronrodNodes {
+		v := f(center + half*x)
+		if x != 0 {
+			v += f(center - half*x)
+		}
+		kronrod += kronrodWeights[i] * v
+		if i%2 == 1 {
+			gauss += gaussWeights[i/2] * v
+		}
+	}
+	return kronrod * half, math.Abs(kronrod-gauss) * math.Abs(half)
+}
+
+// Returns the integral of f from a to b and an estimate of its absolute error, calculated with adaptive
+// Gauss-Kronrod quadrature where an interval is halved until its error estimate is within the tolerance
+func integrate(f func(float64) float64, a, b float64, o integralOptions) (float64, float64) {
+	res, err := gaussKronrod(f, a, b)
+	return adapt(f, a, b, res, err, math.Max(o.tol, o.tol*math.Abs(res)), o.maxDepth)
+}
+
+// Returns the integral of f on [a, b] given its estimate res with the error err, halving [a, b] while the error is
+// larger than tol and depth is not zero, where each half is given half of tol
+func adapt(f func(float64) float64, a, b, res, err, tol float64, depth int) (float64, float64) {
+	if err <= tol || depth == 0 || math.IsNaN(err) {
+		return res, err
+	}
+	m := (a + b) / 2
+	left, leftErr := gaussKronrod(f, a, m)
+	right, rightErr := gaussKronrod(f, m, b)
+	left, leftErr = adapt(f, a, m, left, leftErr, tol/2, depth-1)
+	right, rightErr = adapt(f, m, b, right, rightErr, tol/2, depth-1)
+	return left + right, leftErr + rightErr
+}
+
+// Returns the nodes of the 15-point Kronrod rule on [-1, 1] in increasing order with the Kronrod weights and the
+// Gauss weights, which are zero for the nodes not in the 7-point Gauss rule
+func kronrodRule() ([]float64, []float64, []float64) {
+	var nodes, kw, gw []float64
+	add := func(x float64, i int) {
+		nodes, kw = append(nodes, x), append(kw, kronrodWeights[i])
+		if i%2 == 1 {
+			gw = append(gw, gaussWeights[i/2])
+		} else {
+			gw = append(gw, 0)
+		}
+	}
+	for i, x := range kronrodNodes {
+		add(-x, i)
+	}
+	for i := len(kronrodNodes) - 2; i >= 0; i-- {
+		add(kronrodNodes[i], i)
+	}
+	return nodes, kw, gw
+}
+
+// Returns the product of the 15-point Kronrod rules of f on the rectangle u[0] to u[1], v[0] to v[1], and the
+// differences to the rules with the 7-point Gauss rule along u and along v as error estimates
+func gaussKronrod2D(f func(u, v float64) float64, u, v [2]float64) (float64, float64, float64) {
+	nodes, kw, gw := kronrodRule()
+	cu, hu := (u[0]+u[1])/2, (u[1]-u[0])/2
+	cv, hv := (v[0]+v[1])/2, (v[1]-v[0])/2
+	var kk, gk, kg float64
+	for i, x := range nodes {
+		for j, y := range nodes {
+			val := f(cu+hu*x, cv+hv*y)
+			kk += kw[i] * kw[j] * val
+			gk += gw[i] * kw[j] * val
+			kg += kw[i] * gw[j] * val
+		}
+	}
+	area := hu * hv
+	return kk * area, math.Abs(kk-gk) * math.Abs(area), math.Abs(kk-kg) * math.Abs(area)
+}
+
+// Returns the integral of f on the rectangle u[0] to u[1], v[0] to v[1] and an estimate of its absolute error,
+// calculated with adaptive cubature where a rectangle is halved along the direction with the larger error estimate
+// until its error estimate is within the tolerance
+func integrate2D(f func(u, v float64) float64, u, v [2]float64, o integralOptions) (float64, float64) {
+	res, errU, errV := gaussKronrod2D(f, u, v)
+	return adapt2D(f, u, v, res, errU, errV, math.Max(o.tol, o.tol*math.Abs(res)), o.maxDepth)
+}
+
+// Returns the integral of f on a rectangle given its estimate res with the errors errU and errV along u and v,
+// halving the rectangle like adapt halves an interval
+func adapt2D(f func(u, v float64) float64, u, v [2]float64, res, errU, errV, tol float64, depth int) (float64, float64) {
+	err := errU + errV
+	if err <= tol || depth == 0 || math.IsNaN(err) {
+		return res, err
+	}
+	a, b := u, v
+	c, d := u, v
+	if errU >= errV {
+		m := (u[0] + u[1]) / 2
+		a[1], c[0] = m, m
+	} else {
+		m := (v[0] + v[1]) / 2
+		b[1], d[0] = m, m
+	}
+	first, firstU, firstV := gaussKronrod2D(f, a, b)
+	second, secondU, secondV := gaussKronrod2D(f, c, d)
+	first, firstErr := adapt2D(f, a, b, first, firstU, firstV, tol/2, depth-1)
+	second, secondErr := adapt2D(f, c, d, second, secondU, secondV, tol/2, depth-1)
+	return first + second, firstErr + secondErr
+}
@@ -0,0 +1,43 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegrate(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func(float64) float64
+		a, b float64
+		exp  float64
+	}{
+		{"x^3", func(x float64) float64 { return x * x * x }, 0, 2, 4},
+		{"sin(x)", math.Sin, 0, math.Pi, 2},
+		{"sqrt(x)", math.Sqrt, 0, 1, 2.0 / 3},
+		{"exp(x)", math.Exp, 1, 0, 1 - math.E},
+		{"1/(1+x^2)", func(x float64) float64 { return 1 / (1 + x*x) }, -100, 100, 2 * math.Atan(100)},
+	}
+	for _, v := range tests {
+		got, err := integrate(v.f, v.a, v.b, newIntegralOptions(nil))
+		if math.Abs(got-v.exp) > 1e-8*math.Max(1, math.Abs(v.exp)) || err > 1e-8*math.Max(1, math.Abs(v.exp)) {
+			t.Error("Test failed: {", v.name, v.a, v.b, " } inputted, expected {", v.exp, "} and got {", got, err, "}")
+		}
+	}
+	// Without halving the interval the error of sqrt at 0 is larger than with the default depth
+	_, coarse := integrate(math.Sqrt, 0, 1, newIntegralOptions([]IntegralOption{WithMaxDepth(0)}))
+	_, fine := integrate(math.Sqrt, 0, 1, newIntegralOptions([]IntegralOption{WithTolerance(1e-12)}))
+	if coarse <= fine || coarse < 1e-6 {
+		t.Error("Test failed: { sqrt(x) } inputted, expected the error {", coarse, "} to be larger than {", fine, "}")
+	}
+	for _, f := range []func(){func() { WithTolerance(0)(&integralOptions{}) }, func() { WithMaxDepth(-1)(&integralOptions{}) }} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: { invalid option } inputted, expected a panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
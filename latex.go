@@ -0,0 +1,407 @@
+package vcalc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The LaTeX symbols of names written as greek letters
+var greek = map[string]string{
+	"alpha": `\alpha`, "beta": `\beta`, "delta": `\delta`, "epsilon": `\epsilon`, "eta": `\eta`, "theta": `\theta`,
+	"kappa": `\kappa`, "lambda": `\lambda`, "mu": `\mu`, "nu": `\nu`, "xi": `\xi`, "pi": `\pi`, "rho": `\rho`,
+	"sigma": `\sigma`, "tau": `\tau`, "phi": `\phi`, "chi": `\chi`, "psi": `\psi`, "omega": `\omega`,
+}
+
+// The LaTeX commands of the functions which are written as a command followed by their arguments in parentheses
+var latexFuncs = map[string]string{
+	"sin": `\sin`, "cos": `\cos`, "tan": `\tan`, "log": `\ln`, "ln": `\ln`, "log10": `\log_{10}`,
+	"asin": `\arcsin`, "acos": `\arccos`, "atan": `\arctan`, "sinh": `\sinh`, "cosh": `\cosh`, "tanh": `\tanh`,
+	"min": `\min`, "max": `\max`, "gamma": `\Gamma`,
+}
+
+// The LaTeX symbols of the comparison operators
+var latexComparisons = map[string]string{
+	"<": "<", ">": ">", "<=": `\leq`, ">=": `\geq`, "==": "=", "!=": `\neq`,
+}
+
+// Returns the LaTeX of the coordinate, constant or parameter name, where a greek name is written as the letter,
+// other names of several letters upright and the digits after the letters as subscript
+func latexName(name string) string {
+	i := strings.IndexAny(name, "0123456789")
+	if i < 0 {
+		i = len(name)
+	}
+	letters, digits := name[:i], name[i:]
+	res := letters
+	if g, ok := greek[letters]; ok {
+		res = g
+	} else if len(letters) > 1 {
+		res = `\mathrm{` + letters + "}"
+	}
+	if digits != "" {
+		res += "_{" + digits + "}"
+	}
+	return res
+}
+
+// Returns s in parentheses if wrap is true
+func parens(s string, wrap bool) string {
+	if wrap {
+		return `\left(` + s + `\right)`
+	}
+	return s
+}
+
+// Returns the tree n as LaTeX
+func (n *node) latex() string {
+	switch n.kind {
+	case numNode:
+		return strconv.FormatFloat(n.value, 'f', -1, 64)
+	case varNode:
+		return latexName(n.name)
+	case negNode:
+		// Unlike in the text of an expression a product is negated without parentheses, like -3 x
+		a := n.args[0]
+		p := a.latexPrecedence()
+		return "-" + parens(a.latex(), p < 2 || p == 3)
+	case opNode:
+		return n.latexOp()
+	default:
+		return n.latexCall()
+	}
+}
+
+// Returns the operator node n as LaTeX, where a division is a fraction which needs no parentheses
+func (n *node) latexOp() string {
+	a, b := n.args[0], n.args[1]
+	pa, pb := a.latexPrecedence(), b.latexPrecedence()
+	switch n.op {
+	case "+":
+		return parens(a.latex(), pa < 1) + " + " + parens(b.latex(), pb < 1 || pb == 3)
+	case "-":
+		return parens(a.latex(), pa < 1) + " - " + parens(b.latex(), pb <= 1 || pb == 3)
+	case "*":
+		left, right := parens(a.latex(), pa < 2), parens(b.latex(), pb <= 3)
+		if strings.IndexAny(right[:1], "0123456789.") == 0 {
+			// Numbers following each other are separated by a dot to not be read as one number
+			return left + ` \cdot ` + right
+		}
+		return left + " " + right
+	case "/":
+		return `\frac{` + a.latex() + "}{" + b.latex() + "}"
+	case "^":
+		return n.latexPower(a, b)
+	default:
+		return parens(a.latex(), pa < 1) + " " + latexComparisons[n.op] + " " + parens(b.latex(), pb < 1)
+	}
+}
+
+// Returns a to the power b as LaTeX, where a fraction or operation as base is in parentheses
+func (n *node) latexPower(a, b *node) string {
+	return parens(a.latex(), a.latexPrecedence() < 5 || (a.kind == opNode && a.op == "/")) + "^{" + b.latex() + "}"
+}
+
+// Binding strength of each kind of node when written as LaTeX, like precedence but a fraction does not need parentheses
+func (n *node) latexPrecedence() int {
+	if n.kind == opNode && n.op == "/" {
+		return 5
+	}
+	return n.precedence()
+}
+
+// Returns the call node n as LaTeX, using the usual notation of the functions which have one
+func (n *node) latexCall() string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.latex()
+	}
+	switch n.name {
+	case "sqrt":
+		return `\sqrt{` + args[0] + "}"
+	case "exp":
+		return "e^{" + args[0] + "}"
+	case "abs":
+		return `\left|` + args[0] + `\right|`
+	case "pow":
+		return n.latexPower(n.args[0], n.args[1])
+	case "besselj":
+		return "J_{" + args[0] + "}" + parens(args[1], true)
+	case "bessely":
+		return "Y_{" + args[0] + "}" + parens(args[1], true)
+	case "legendre":
+		return "P_{" + args[0] + "}^{" + args[1] + "}" + parens(args[2], true)
+	case "ylm":
+		return "Y_{" + args[0] + "}^{" + args[1] + "}" + parens(args[2]+", "+args[3], true)
+	case "if", "piecewise":
+		cases := make([]string, 0, len(args)/2+1)
+		for i := 0; i+1 < len(args); i += 2 {
+			cases = append(cases, args[i+1]+" & "+args[i])
+		}
+		cases = append(cases, args[len(args)-1]+` & \text{otherwise}`)
+		return `\begin{cases} ` + strings.Join(cases, ` \\ `) + ` \end{cases}`
+	}
+	name, ok := latexFuncs[n.name]
+	if !ok {
+		name = `\operatorname{` + n.name + "}"
+	}
+	return name + parens(strings.Join(args, ", "), true)
+}
+
+// Returns the LaTeX of the unit vector in the direction of the coordinate name
+func latexUnit(name string) string {
+	if g, ok := greek[name]; ok {
+		return `\hat{\boldsymbol{` + g + "}}"
+	}
+	return `\hat{\mathbf{` + name + "}}"
+}
+
+// Returns the vector field with the expressions as components in the unit vectors of coordsys as LaTeX,
+// a sum of each component that is not zero times its unit vector
+func (o fieldOptions) vectorLatex(coordsys string, expressions ...string) string {
+	res := ""
+	for i, name := range coordNames(coordsys) {
+		e := o.mustParse(expressions[i])
+		if e.is(0) {
+			continue
+		}
+		sign := " + "
+		term := parens(e.latex(), e.latexPrecedence() < 2)
+		if strings.HasPrefix(term, "-") {
+			// A negative number, negation or product with a negative first factor
+			sign, term = " - ", term[1:]
+		}
+		if term == "1" {
+			term = ""
+		} else {
+			term += " "
+		}
+		res += sign + term + latexUnit(name)
+	}
+	switch {
+	case res == "":
+		return "0"
+	case strings.HasPrefix(res, " + "):
+		return res[3:]
+	default:
+		return "-" + res[3:]
+	}
+}
+
+// Returns the vector field with the expressions as components as canonical text like (x, -y, 0)
+func (o fieldOptions) vectorString(expressions ...string) string {
+	components := make([]string, len(expressions))
+	for i, e := range expressions {
+		components[i] = o.mustParse(e).String()
+	}
+	return "(" + strings.Join(components, ", ") + ")"
+}
+
+// A term of a differential operator, factor times the partial derivative of inner with respect to the coordinate
+type partialTerm struct {
+	sign   float64
+	factor *node
+	inner  *node
+	coord  string
+}
+
+// Returns the parts of the scale factors of the coordinates of coordsys, where the scale factor of a coordinate is
+// the product of its parts, so that the parts which do not depend on a coordinate can be moved out of its derivative
+func scaleFactorParts(coordsys string) [][]*node {
+	switch coordsys {
+	case "cyl":
+		return [][]*node{nil, {variable("r")}, nil}
+	case "sph":
+		return [][]*node{nil, {variable("r")}, {variable("r"), call("sin", variable("theta"))}}
+	default:
+		return [][]*node{nil, nil, nil}
+	}
+}
+
+// Returns the term sign/product(outer) times the derivative with respect to coord of product(mul)/product(div) times
+// inner, where the parts of mul and div which cancel are removed and those which do not depend on coord are moved
+// out of the derivative
+func newPartialTerm(sign float64, outer, mul, div []*node, inner *node, coord string) partialTerm {
+	factor, moved := num(1), false
+	for _, n := range outer {
+		factor = op("/", factor, n)
+	}
+	div = append([]*node(nil), div...)
+	for _, n := range mul {
+		cancelled := false
+		for k, d := range div {
+			if d != nil && d.String() == n.String() {
+				div[k], cancelled = nil, true
+				break
+			}
+		}
+		switch {
+		case cancelled:
+		case n.uses(coord):
+			inner, moved = op("*", n, inner), true
+		default:
+			factor = op("*", factor, n)
+		}
+	}
+	for _, d := range div {
+		switch {
+		case d == nil:
+		case d.uses(coord):
+			inner, moved = op("/", inner, d), true
+		default:
+			factor = op("/", factor, d)
+		}
+	}
+	if moved {
+		inner = inner.simplify()
+	}
+	return partialTerm{sign, factor.simplify(), inner, coord}
+}
+
+// Returns the sum of terms as LaTeX, where a term whose inner expression does not depend on its coordinate is zero
+// and left out
+func latexPartials(terms []partialTerm) string {
+	res := ""
+	for _, t := range terms {
+		if !t.inner.uses(t.coord) {
+			continue
+		}
+		d := `\frac{\partial}{\partial ` + latexName(t.coord) + "}" + parens(t.inner.latex(), true)
+		if !t.factor.is(1) {
+			d = parens(t.factor.latex(), t.factor.latexPrecedence() < 2) + " " + d
+		}
+		if t.sign < 0 {
+			res += " - " + d
+		} else {
+			res += " + " + d
+		}
+	}
+	switch {
+	case res == "":
+		return "0"
+	case strings.HasPrefix(res, " + "):
+		return res[3:]
+	default:
+		return "-" + res[3:]
+	}
+}
+
+// Returns the calculation of the sum of terms with the derivatives found symbolically, simplified
+func sumPartials(terms []partialTerm) *node {
+	res := num(0)
+	for _, t := range terms {
+		res = op("+", res, op("*", op("*", num(t.sign), t.factor), t.inner.diff(t.coord)))
+	}
+	return res.simplify()
+}
+
+// Returns an equation of LaTeX for the vector operator written as lhs of a field in coordsys, where the component i is the sum of
+// terms[i], written with its partial derivatives and with the derivatives calculated symbolically
+func (o fieldOptions) vectorPartialsLatex(lhs, coordsys string, terms [][]partialTerm) string {
+	res := ""
+	components := make([]string, len(terms))
+	for i, name := range coordNames(coordsys) {
+		components[i] = sumPartials(terms[i]).String()
+		d := latexPartials(terms[i])
+		if d == "0" {
+			continue
+		}
+		nonzero := 0
+		for _, t := range terms[i] {
+			if t.inner.uses(t.coord) {
+				nonzero++
+			}
+		}
+		if nonzero > 1 {
+			d = parens(d, true)
+		}
+		if strings.HasPrefix(d, "-") {
+			res += " - " + d[1:] + " " + latexUnit(name)
+		} else {
+			res += " + " + d + " " + latexUnit(name)
+		}
+	}
+	switch {
+	case res == "":
+		res = "0"
+	case strings.HasPrefix(res, " + "):
+		res = res[3:]
+	default:
+		res = "-" + res[3:]
+	}
+	return lhs + " = " + res + " = " + o.vectorLatex(coordsys, components...)
+}
+
+// Returns the gradient of scalarField as LaTeX, the equation of \nabla f, the gradient written with the partial
+// derivatives of the expression in the coordinate system of the field and the gradient found symbolically
+func (s scalarField) GradLaTeX() string {
+	h := scaleFactorParts(s.coordsys)
+	terms := make([][]partialTerm, 3)
+	for i, name := range coordNames(s.coordsys) {
+		terms[i] = []partialTerm{newPartialTerm(1, h[i], nil, nil, s.tree, name)}
+	}
+	return s.opts.vectorPartialsLatex(`\nabla f`, s.coordsys, terms)
+}
+
+// Returns the laplacian of scalarField as LaTeX, the equation of \nabla^{2} f, the laplacian written with the
+// partial derivatives in the coordinate system of the field and the laplacian found symbolically
+func (s scalarField) LaplacianLaTeX() string {
+	h := scaleFactorParts(s.coordsys)
+	var terms []partialTerm
+	for i, name := range coordNames(s.coordsys) {
+		// 1/(h1 h2 h3) d/du_i (h1 h2 h3/h_i^2 df/du_i)
+		terms = append(terms, newPartialTerm(1, volumeParts(h), otherParts(h, i), h[i], s.tree.diff(name).simplify(), name))
+	}
+	return `\nabla^{2} f = ` + latexPartials(terms) + " = " + sumPartials(terms).latex()
+}
+
+// Returns the divergence of vectorField as LaTeX, the equation of \nabla \cdot \mathbf{F}, the divergence written
+// with the partial derivatives of the components in the coordinate system of the field and the divergence found
+// symbolically
+func (v vectorField) DivLaTeX() string {
+	h := scaleFactorParts(v.coordsys)
+	F := []*node{v.treeCoord1, v.treeCoord2, v.treeCoord3}
+	var terms []partialTerm
+	for i, name := range coordNames(v.coordsys) {
+		// 1/(h1 h2 h3) d/du_i (h1 h2 h3/h_i F_i)
+		terms = append(terms, newPartialTerm(1, volumeParts(h), otherParts(h, i), nil, F[i], name))
+	}
+	return `\nabla \cdot \mathbf{F} = ` + latexPartials(terms) + " = " + sumPartials(terms).latex()
+}
+
+// Returns the rotation of vectorField as LaTeX, the equation of \nabla \times \mathbf{F}, the rotation written with
+// the partial derivatives of the components in the coordinate system of the field and the rotation found symbolically
+func (v vectorField) RotLaTeX() string {
+	h := scaleFactorParts(v.coordsys)
+	F := []*node{v.treeCoord1, v.treeCoord2, v.treeCoord3}
+	names := coordNames(v.coordsys)
+	terms := make([][]partialTerm, 3)
+	for i := range terms {
+		// 1/(h_j h_k) (d/du_j (h_k F_k) - d/du_k (h_j F_j)) for the cyclic order i, j, k
+		j, k := (i+1)%3, (i+2)%3
+		outer := append(append([]*node(nil), h[j]...), h[k]...)
+		terms[i] = []partialTerm{
+			newPartialTerm(1, outer, h[k], nil, F[k], names[j]),
+			newPartialTerm(-1, outer, h[j], nil, F[j], names[k])}
+	}
+	return v.opts.vectorPartialsLatex(`\nabla \times \mathbf{F}`, v.coordsys, terms)
+}
+
+// Returns the parts of the product of the scale factors h
+func volumeParts(h [][]*node) []*node {
+	var res []*node
+	for _, parts := range h {
+		res = append(res, parts...)
+	}
+	return res
+}
+
+// Returns the parts of the product of the scale factors h but the scale factor i
+func otherParts(h [][]*node, i int) []*node {
+	var res []*node
+	for j, parts := range h {
+		if j != i {
+			res = append(res, parts...)
+		}
+	}
+	return res
+}
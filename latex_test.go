@@ -0,0 +1,100 @@
+package vcalc
+
+import (
+	"testing"
+)
+
+func TestLatex(t *testing.T) {
+	var tests = []struct {
+		expression string
+		exp        string
+	}{
+		{"3x^2+5cos(y^2)-3z", `3 x^{2} + 5 \cos\left(y^{2}\right) - 3 z`},
+		{"-3sin(2r^3)^5+phi*theta^2", `-3 \sin\left(2 r^{3}\right)^{5} + \phi \theta^{2}`},
+		{"1/r*(x+1)", `\frac{1}{r} \left(x + 1\right)`},
+		{"2*3*x", `2 \cdot 3 x`},
+		{"x^-2*(x/y)^2", `x^{-2} \left(\frac{x}{y}\right)^{2}`},
+		{"sqrt(x^2+y^2)*exp(-x)*abs(z)", `\sqrt{x^{2} + y^{2}} e^{-x} \left|z\right|`},
+		{"if(r<1, (3-r^2)/2, 1/r)", `\begin{cases} \frac{3 - r^{2}}{2} & r < 1 \\ \frac{1}{r} & \text{otherwise} \end{cases}`},
+		{"besselj(0, 2.4r)*ylm(2, 1, theta, phi)", `J_{0}\left(2.4 r\right) Y_{2}^{1}\left(\theta, \phi\right)`},
+		{"x-(y-z)-(-y)", `x - \left(y - z\right) - \left(-y\right)`},
+		{"2pi r+k2*x1", `2 \pi r + k_{2} x_{1}`},
+		{"rate*log10(x)+atan2(y, x)", `\mathrm{rate} \log_{10}\left(x\right) + \operatorname{atan2}\left(y, x\right)`},
+		{"-(x+y)+(x<=1)*x", `-\left(x + y\right) + \left(x \leq 1\right) x`},
+	}
+	for _, v := range tests {
+		if exp := mustParse(v.expression).latex(); exp != v.exp {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestFieldLatex(t *testing.T) {
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{NewScalarField("x^2 y", "car").Diff("x").LaTeX(), `2 x y`},
+		{NewScalarField("q/r^2", "sph", WithParams("q")).LaTeX(), `\frac{q}{r^{2}}`},
+		{NewVectorField("x^2", "-y", "0", "car").LaTeX(), `x^{2} \hat{\mathbf{x}} - y \hat{\mathbf{y}}`},
+		{NewVectorField("-1", "r*sin(theta)+1", "2", "sph").LaTeX(), `-\hat{\mathbf{r}} + \left(r \sin\left(\theta\right) + 1\right) \hat{\boldsymbol{\theta}} + 2 \hat{\boldsymbol{\phi}}`},
+		{NewVectorField("0", "0", "0", "cyl").LaTeX(), `0`},
+		{NewVectorField("-2x", "0", "-x*y", "car").LaTeX(), `-2 x \hat{\mathbf{x}} - x y \hat{\mathbf{z}}`},
+		{NewVectorField2D("0", "r^2", "polar").LaTeX(), `r^{2} \hat{\boldsymbol{\phi}}`},
+		{NewScalarField2D("x y", "car2").LaTeX(), `x y`},
+		{NewScalarFieldN("x1*x2", []string{"x1", "x2"}).LaTeX(), `x_{1} x_{2}`},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestOperatorLatex(t *testing.T) {
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{NewScalarField("x^2 y", "car").GradLaTeX(),
+			`\nabla f = \frac{\partial}{\partial x}\left(x^{2} y\right) \hat{\mathbf{x}} + \frac{\partial}{\partial y}\left(x^{2} y\right) \hat{\mathbf{y}} = 2 x y \hat{\mathbf{x}} + x^{2} \hat{\mathbf{y}}`},
+		{NewScalarField("r^2 cos(phi)", "cyl").GradLaTeX(),
+			`\nabla f = \frac{\partial}{\partial r}\left(r^{2} \cos\left(\phi\right)\right) \hat{\mathbf{r}} + \frac{1}{r} \frac{\partial}{\partial \phi}\left(r^{2} \cos\left(\phi\right)\right) \hat{\boldsymbol{\phi}} = 2 r \cos\left(\phi\right) \hat{\mathbf{r}} - r \sin\left(\phi\right) \hat{\boldsymbol{\phi}}`},
+		{NewScalarField("r^2", "cyl").LaplacianLaTeX(), `\nabla^{2} f = \frac{1}{r} \frac{\partial}{\partial r}\left(2 r^{2}\right) = 4`},
+		{NewScalarField("r^2 cos(theta)", "sph").LaplacianLaTeX(),
+			`\nabla^{2} f = \frac{1}{r^{2}} \frac{\partial}{\partial r}\left(2 r^{3} \cos\left(\theta\right)\right) + \frac{1}{r^{2} \sin\left(\theta\right)} \frac{\partial}{\partial \theta}\left(-\sin\left(\theta\right)^{2} r^{2}\right) = 4 \cos\left(\theta\right)`},
+		{NewVectorField("x^2", "-y", "0", "car").DivLaTeX(),
+			`\nabla \cdot \mathbf{F} = \frac{\partial}{\partial x}\left(x^{2}\right) + \frac{\partial}{\partial y}\left(-y\right) = 2 x - 1`},
+		{NewVectorField("r", "sin(phi)", "z", "cyl").DivLaTeX(),
+			`\nabla \cdot \mathbf{F} = \frac{1}{r} \frac{\partial}{\partial r}\left(r^{2}\right) + \frac{1}{r} \frac{\partial}{\partial \phi}\left(\sin\left(\phi\right)\right) + \frac{\partial}{\partial z}\left(z\right) = 3 + \frac{\cos\left(\phi\right)}{r}`},
+		{NewVectorField("-y", "x", "0", "car").RotLaTeX(),
+			`\nabla \times \mathbf{F} = \left(\frac{\partial}{\partial x}\left(x\right) - \frac{\partial}{\partial y}\left(-y\right)\right) \hat{\mathbf{z}} = 2 \hat{\mathbf{z}}`},
+		{NewVectorField("0", "0", "r sin(theta)", "sph").RotLaTeX(),
+			`\nabla \times \mathbf{F} = \frac{1}{r \sin\left(\theta\right)} \frac{\partial}{\partial \theta}\left(\sin\left(\theta\right)^{2} r\right) \hat{\mathbf{r}} - \frac{1}{r} \frac{\partial}{\partial r}\left(r^{2} \sin\left(\theta\right)\right) \hat{\boldsymbol{\theta}} = 2 \cos\left(\theta\right) \hat{\mathbf{r}} - 2 \sin\left(\theta\right) \hat{\boldsymbol{\theta}}`},
+		{NewVectorField("x", "y", "z", "car").RotLaTeX(), `\nabla \times \mathbf{F} = 0 = 0`},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{NewScalarField("3x^2 y+5cos(z)", "car").String(), "3*x^2*y+5*cos(z)"},
+		{NewScalarField("x^2*y", "car").Diff("x").String(), "2*x*y"},
+		{NewVectorField("0", "r", "-z", "cyl").String(), "(0, r, -z)"},
+		{NewVectorField2D("-y", "x", "car2").String(), "(-y, x)"},
+		{NewScalarField2D("r^2 cos(phi)", "polar").String(), "r^2*cos(phi)"},
+		{NewScalarFieldN("x1 x2", []string{"x1", "x2"}).String(), "x1*x2"},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package vcalc
+
+import (
+	"sort"
+)
+
+// Settings of a field that are given as options to its constructor
+type fieldOptions struct {
+	singularity SingularityStrategy
+	params      []string
+	values      map[string]float64
+	funcs       funcRegistry
+	strict      bool
+	time        float64
+	part        complexPart
+}
+
+// A FieldOption changes a setting of a field when given to NewScalarField, NewVectorField,
+// NewScalarField2D, NewVectorField2D or NewScalarFieldN
+type FieldOption func(*fieldOptions)
+
+// Returns the settings given by opts, every setting not given keeps its default value
+func newFieldOptions(opts []FieldOption) fieldOptions {
+	o := fieldOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Returns an option setting how a field handles the singular points of its coordinate system
+func WithSingularity(strategy SingularityStrategy) FieldOption {
+	return func(o *fieldOptions) {
+		o.singularity = strategy
+	}
+}
+
+// Returns an option declaring names which can be used as parameters in the expressions of a field
+// The value of each parameter is given by the method Bind or BindParams of the field before it is evaluated
+func WithParams(names ...string) FieldOption {
+	return func(o *fieldOptions) {
+		o.params = append(o.params, names...)
+	}
+}
+
+// Returns an option letting the expressions of a field call the custom functions registered in r
+// The expressions are parsed with the functions in r when the field is defined, so functions registered
+// in r after that can not be called by the field
+func WithFuncs(r funcRegistry) FieldOption {
+	return func(o *fieldOptions) {
+		o.funcs = r
+	}
+}
+
+// Returns an option disabling implicit multiplication in the expressions of a field,
+// so that every multiplication, also of a coefficient like 3*x, must be written with *
+func WithStrictSyntax() FieldOption {
+	return func(o *fieldOptions) {
+		o.strict = true
+	}
+}
+
+// Returns a copy of o where the parameters in values are bound, panics if a name is not a declared parameter
+func (o fieldOptions) bind(values map[string]float64) fieldOptions {
+	bound := make(map[string]float64, len(o.values)+len(values))
+	for name, value := range o.values {
+		bound[name] = value
+	}
+	// Sort the names so that the same undeclared name is reported on every call
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !o.isParam(name) {
+			panic("Parameter " + name + " is not declared, declare it with WithParams")
+		}
+		bound[name] = values[name]
+	}
+	o.values = bound
+	return o
+}
+
+// Returns the settings of a field combining fields with the settings o and p, which keep the parameters and
+// bound values of both and can call the custom functions of both, the other settings are those of o
+// Panics if the fields are at different times, a parameter is bound to different values or a function name is
+// registered differently in both
+func (o fieldOptions) merge(p fieldOptions) fieldOptions {
+	if o.time != p.time {
+		panic("Can not combine the fields, they are at different times")
+	}
+	res := o
+	res.params = append([]string(nil), o.params...)
+	for _, name := range p.params {
+		if !o.isParam(name) {
+			res.params = append(res.params, name)
+		}
+	}
+	res.values = make(map[string]float64, len(o.values)+len(p.values))
+	for name, value := range o.values {
+		res.values[name] = value
+	}
+	for name, value := range p.values {
+		if v, ok := res.values[name]; ok && v != value {
+			panic("Can not combine the fields, parameter " + name + " is bound to different values")
+		}
+		res.values[name] = value
+	}
+	shared := true
+	for name, f := range p.funcs.funcs {
+		g, ok := o.funcs.funcs[name]
+		if ok && g != f {
+			panic("Can not combine the fields, function " + name + " is registered differently")
+		}
+		shared = shared && ok
+	}
+	// The registry of o is kept when it has every function of p, otherwise the functions of both are copied to a new one
+	if !shared {
+		res.funcs = NewFuncRegistry()
+		for name, f := range o.funcs.funcs {
+			res.funcs.funcs[name] = f
+		}
+		for name, f := range p.funcs.funcs {
+			res.funcs.funcs[name] = f
+		}
+	}
+	return res
+}
+
+// Returns true if name is a declared parameter
+func (o fieldOptions) isParam(name string) bool {
+	for _, v := range o.params {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the point c followed by the values of the parameters and the names coords followed by the names of the parameters
+// Panics if a parameter is not bound
+func (o fieldOptions) withParams(c []float64, coords []string) ([]float64, []string) {
+	if len(o.params) == 0 {
+		return c, coords
+	}
+	point := append(append([]float64(nil), c...), make([]float64, len(o.params))...)
+	names := append(append([]string(nil), coords...), o.params...)
+	for i, name := range o.params {
+		value, ok := o.values[name]
+		if !ok {
+			panic("Parameter " + name + " is not bound, bind it with Bind or BindParams")
+		}
+		point[len(c)+i] = value
+	}
+	return point, names
+}
+
+// Returns the point c followed by the values of the parameters and the time, and the names coords followed by the names
+// of the parameters and t, panics if a parameter is not bound
+func (o fieldOptions) withTime(c []float64, coords []string) ([]float64, []string) {
+	c, coords = o.withParams(c, coords)
+	return append(append([]float64(nil), c...), o.time), append(append([]string(nil), coords...), timeName)
+}
@@ -0,0 +1,109 @@
+package vcalc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckParams(t *testing.T) {
+	var tests = []struct {
+		params []string
+		exp    bool // true if checkParams panics
+	}{
+		{[]string{"q"}, false},
+		{[]string{"q", "k2"}, false},
+		{nil, false},
+		{[]string{"2k"}, true},
+		{[]string{"k_1"}, true},
+		{[]string{"cos"}, true},
+		{[]string{"theta"}, true},
+		{[]string{"q", "q"}, true},
+	}
+	for _, v := range tests {
+		panicked := func() (panicked bool) {
+			defer func() {
+				panicked = recover() != nil
+			}()
+			checkParams(fieldOptions{params: v.params})
+			return
+		}()
+		if panicked != v.exp {
+			t.Error("Test failed: {", v.params, " } inputted, expected panic {", v.exp, "} and got {", panicked, "}")
+		}
+	}
+}
+
+func TestCheckCoordsParams(t *testing.T) {
+	var tests = []struct {
+		expression string
+		coordsys   string
+		params     []string
+		exp        bool // true if checkCoords panics
+	}{
+		{"q/r^2", "sph", []string{"q"}, false},
+		{"q/r^2", "sph", nil, true},
+		{"k*x+y", "car", []string{"k"}, false},
+		{"k*x+theta", "car", []string{"k"}, true},
+		{"x+y", "car", []string{"phi"}, true},
+		{"x+y", "car", []string{"sin"}, true},
+		{"1e-3k*x+2.5E+2y", "car", []string{"k"}, false},
+		{"e*x", "car", nil, true},
+		{"x+y", "car", []string{"pi"}, true},
+		{"2pi r+k z", "cyl", []string{"k"}, false},
+		{"r(z+1)-k(phi)", "cyl", []string{"k"}, false},
+		{"foo(x)", "car", nil, true},
+	}
+	for _, v := range tests {
+		panicked := func() (panicked bool) {
+			defer func() {
+				panicked = recover() != nil
+			}()
+			checkCoords(v.expression, v.coordsys, fieldOptions{params: v.params})
+			return
+		}()
+		if panicked != v.exp {
+			t.Error("Test failed: {", v.expression, v.coordsys, v.params, " } inputted, expected panic {", v.exp, "} and got {", panicked, "}")
+		}
+	}
+}
+
+func TestWithParams(t *testing.T) {
+	var tests = []struct {
+		opts      fieldOptions
+		c         []float64
+		coords    []string
+		expPoint  []float64
+		expCoords []string
+	}{
+		{newFieldOptions([]FieldOption{WithParams("q")}).bind(map[string]float64{"q": 2}), []float64{1, 2, 3}, []string{"r", "theta", "phi"}, []float64{1, 2, 3, 2}, []string{"r", "theta", "phi", "q"}},
+		{newFieldOptions([]FieldOption{WithParams("a", "b")}).bind(map[string]float64{"b": 5}).bind(map[string]float64{"a": -1}), []float64{1, 2}, []string{"x", "y"}, []float64{1, 2, -1, 5}, []string{"x", "y", "a", "b"}},
+		{newFieldOptions(nil), []float64{1, 2}, []string{"x", "y"}, []float64{1, 2}, []string{"x", "y"}},
+	}
+	for _, v := range tests {
+		if point, coords := v.opts.withParams(v.c, v.coords); !reflect.DeepEqual(point, v.expPoint) || !reflect.DeepEqual(coords, v.expCoords) {
+			t.Error("Test failed: {", v.opts, v.c, v.coords, " } inputted, expected {", v.expPoint, v.expCoords, "} and got {", point, coords, "}")
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	s := NewScalarField("q/r^2", "sph", WithParams("q"))
+	if exp := s.Bind("q", 2).Grad([]float64{1, 1, 0}); !almostEqual(exp, []float64{-4, 0, 0}, 1e-6) {
+		t.Error("Test failed: { q=2 } inputted, expected {", []float64{-4, 0, 0}, "} and got {", exp, "}")
+	}
+	if exp := s.Bind("q", -1).Grad([]float64{1, 1, 0}); !almostEqual(exp, []float64{2, 0, 0}, 1e-6) {
+		t.Error("Test failed: { q=-1 } inputted, expected {", []float64{2, 0, 0}, "} and got {", exp, "}")
+	}
+	g := NewScalarField("k*sin(x)+q*z", "car", WithParams("k", "q")).BindParams(map[string]float64{"k": 2, "q": 3})
+	if exp := g.Grad([]float64{0, 0, 0}); !almostEqual(exp, []float64{2, 0, 3}, 1e-6) {
+		t.Error("Test failed: { k=2 q=3 } inputted, expected {", []float64{2, 0, 3}, "} and got {", exp, "}")
+	}
+	v := NewVectorField("k*x", "k*y", "k*z", "car", WithParams("k")).Bind("k", 2)
+	if exp := v.Div([]float64{1, 2, 3}); !almostEqual([]float64{exp}, []float64{6}, 1e-6) {
+		t.Error("Test failed: { k=2 } inputted, expected {", 6, "} and got {", exp, "}")
+	}
+	s2 := NewScalarField2D("a*r^2", "polar", WithParams("a")).Bind("a", 3)
+	if exp := s2.Laplacian([]float64{1, 1}); !almostEqual([]float64{exp}, []float64{12}, 1e-4) {
+		t.Error("Test failed: { a=3 } inputted, expected {", 12, "} and got {", exp, "}")
+	}
+}
@@ -0,0 +1,396 @@
+package vcalc
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Returns the derivative of the tree n with respect to name if it does not contain name, so n is linear in name
+func (n *node) slope(name string) (*node, bool) {
+	k := n.diff(name).simplify()
+	return k, !k.uses(name) && !k.is(0)
+}
+
+// The antiderivatives of the functions of a linear argument u, divided by the slope of u
+var antiderivatives = map[string]func(u *node) *node{
+	"sin":  func(u *node) *node { return neg(call("cos", u)) },
+	"cos":  func(u *node) *node { return call("sin", u) },
+	"exp":  func(u *node) *node { return call("exp", u) },
+	"sinh": func(u *node) *node { return call("cosh", u) },
+	"cosh": func(u *node) *node { return call("sinh", u) },
+}
+
+// Returns an antiderivative of the tree n with respect to the coordinate name and true, or false if it is not
+// found. Sums, constant factors, powers and the functions in antiderivatives of an argument linear in name are
+// integrated, which covers polynomials and the common potentials
+func (n *node) antiderivative(name string) (*node, bool) {
+	if !n.uses(name) {
+		return op("*", n, variable(name)), true
+	}
+	switch n.kind {
+	case varNode:
+		return op("/", op("^", n, num(2)), num(2)), true
+	case negNode:
+		if a, ok := n.args[0].antiderivative(name); ok {
+			return neg(a), true
+		}
+	case opNode:
+		a, b := n.args[0], n.args[1]
+		switch n.op {
+		case "+", "-":
+			A, okA := a.antiderivative(name)
+			B, okB := b.antiderivative(name)
+			if okA && okB {
+				return op(n.op, A, B), true
+			}
+		case "*":
+			if !a.uses(name) {
+				if B, ok := b.antiderivative(name); ok {
+					return op("*", a, B), true
+				}
+			} else if !b.uses(name) {
+				if A, ok := a.antiderivative(name); ok {
+					return op("*", A, b), true
+				}
+			}
+		case "/":
+			if !b.uses(name) {
+				A, ok := a.antiderivative(name)
+				if ok {
+					return op("/", A, b), true
+				}
+				return nil, false
+			}
+			// a/u is a*u^-1 and a/u^b is a*u^-b for a constant a
+			if k, ok := b.slope(name); ok && !a.uses(name) {
+				return op("/", op("*", a, call("ln", call("abs", b))), k), true
+			}
+			if b.kind == opNode && b.op == "^" && !a.uses(name) {
+				return op("*", a, op("^", b.args[0], neg(b.args[1]))).antiderivative(name)
+			}
+		case "^":
+			if k, ok := a.slope(name); ok && !b.uses(name) {
+				if b.is(-1) {
+					return op("/", call("ln", call("abs", a)), k), true
+				}
+				e := op("+", b, num(1)).simplify()
+				return op("/", op("^", a, e), op("*", e, k)), true
+			}
+			// a^u = exp(u*ln(a)) for a constant a
+			if k, ok := b.slope(name); ok && !a.uses(name) {
+				return op("/", n, op("*", call("ln", a), k)), true
+			}
+		}
+	case callNode:
+		if f, found := antiderivatives[n.name]; found && n.fn == nil && len(n.args) == 1 {
+			if k, ok := n.args[0].slope(name); ok {
+				return op("/", f(n.args[0]), k), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Returns the scale factors of the coordinates of coordsys, the length of a step of one along each coordinate
+func scaleFactors(coordsys string) []*node {
+	switch coordsys {
+	case "cyl":
+		return []*node{num(1), variable("r"), num(1)}
+	case "sph":
+		return []*node{num(1), variable("r"), op("*", variable("r"), call("sin", variable("theta")))}
+	default:
+		return []*node{num(1), num(1), num(1)}
+	}
+}
+
+// Returns the points vectorField is sampled at around the point c, in the coordinates of vectorField
+// The points are the same for every call, so that a check gives the same result each time
+func (v vectorField) samples(c []float64, n int) [][]float64 {
+	random := rand.New(rand.NewSource(1))
+	p := toCartesian(c, v.coordsys)
+	res := make([][]float64, n)
+	for i := range res {
+		q := make([]float64, 3)
+		for k := range q {
+			q[k] = p[k] + 2*random.Float64() - 1
+		}
+		res[i] = fromCartesian(q, v.coordsys)
+	}
+	return res
+}
+
+// Returns n random points of region in its coordinates, the same points for every call so that a check gives the
+// same result each time. Fewer points are returned for a region given by an indicator
+func (g region) samples(n int) [][]float64 {
+	random := rand.New(rand.NewSource(1))
+	var res [][]float64
+	for i := 0; i < n; i++ {
+		c := make([]float64, 3)
+		for k := range c {
+			c[k] = g.lower[k] + random.Float64()*(g.upper[k]-g.lower[k])
+		}
+		if g.inside(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Returns true if the largest magnitude of the rotation of vectorField at random points of region is at most tol,
+// so the field is conservative there, and the largest magnitude. Singular points are skipped
+func (v vectorField) ConservativeCheck(g region, tol float64) (bool, float64) {
+	var largest float64
+	for _, c := range g.samples(100) {
+		rot, err := v.TryRot(g.in(c, v.coordsys))
+		if err != nil {
+			continue
+		}
+		largest = math.Max(largest, math.Sqrt(rot[0]*rot[0]+rot[1]*rot[1]+rot[2]*rot[2]))
+	}
+	return largest <= tol, largest
+}
+
+// Returns the potential of vectorField found symbolically, with the coordinates integrated one after another, and
+// true, or false if a component has no antiderivative in antiderivatives or the result does not have the gradient
+// vectorField at points around ref
+func (v vectorField) symbolicPotential(ref []float64) (*node, bool) {
+	coords := coordNames(v.coordsys)
+	h := scaleFactors(v.coordsys)
+	// G[i] is the derivative of the potential with respect to the coordinate i
+	G := make([]*node, 3)
+	for i, e := range v.expressions() {
+		G[i] = op("*", h[i], v.opts.mustParse(e)).simplify()
+	}
+	res := num(0)
+	for i, name := range coords {
+		rest := op("-", G[i], res.diff(name)).simplify()
+		a, ok := rest.antiderivative(name)
+		if !ok {
+			return nil, false
+		}
+		res = op("+", res, a).simplify()
+	}
+	// The simplification may not see that a remainder does not depend on an earlier coordinate, so the result is
+	// compared to the field, with values for the parameters which are not bound
+	o := v.opts
+	for i, name := range o.params {
+		if _, ok := o.values[name]; !ok {
+			o = o.bind(map[string]float64{name: 0.7 + 0.1*float64(i)})
+		}
+	}
+	for _, c := range append(v.samples(ref, 5), ref) {
+		p, names := o.withTime(c, coords)
+		for i, name := range coords {
+			got, exp := res.diff(name).eval(p, names), G[i].eval(p, names)
+			if math.IsNaN(got) || math.Abs(got-exp) > 1e-9*math.Max(1, math.Abs(exp)) {
+				return nil, false
+			}
+		}
+	}
+	return res, true
+}
+
+// Returns a copy of o with a copy of its registry, where functions can be registered without changing the
+// registry of the field o is from
+func (o fieldOptions) ownFuncs() fieldOptions {
+	funcs := NewFuncRegistry()
+	for name, f := range o.funcs.funcs {
+		funcs.funcs[name] = f
+	}
+	o.funcs = funcs
+	return o
+}
+
+// Returns base, or base followed by the smallest number making it a name which is not a function
+func (r funcRegistry) unusedName(base string) string {
+	name := base
+	for i := 1; r.isFUNC(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	return name
+}
+
+// Returns the potential of vectorField as a field calling a custom function, which integrates vectorField along the
+// straight line from ref to the point, and whose partial derivatives are the components times the scale factors
+func (v vectorField) pathPotential(ref []float64) scalarField {
+	o := v.opts.ownFuncs()
+	funcs := o.funcs
+	name := funcs.unusedName("potential")
+	start := toCartesian(ref, v.coordsys)
+	h := scaleFactors(v.coordsys)
+	coords := coordNames(v.coordsys)
+	deriv := make([]string, 3)
+	for i := range deriv {
+		i := i
+		deriv[i] = "d" + coords[i] + name
+		funcs.RegisterFunc(deriv[i], 3, func(c ...float64) float64 {
+			return h[i].eval(c, coords) * v.eval(c)[i]
+		})
+	}
+	funcs.RegisterFunc(name, 3, func(c ...float64) float64 {
+		end := toCartesian(c, v.coordsys)
+		line := NewCurveFunc(func(t float64) []float64 {
+			return []float64{start[0] + t*(end[0]-start[0]), start[1] + t*(end[1]-start[1]), start[2] + t*(end[2]-start[2])}
+		}, "car")
+		res, _ := lineIntegral(v.eval, v.coordsys, line, 0, 1, nil)
+		return res
+	}, deriv...)
+	return NewScalarField(funcs.call(name, variable(coords[0]), variable(coords[1]), variable(coords[2])).String(), v.coordsys, withOptions(o))
+}
+
+// ErrNotConservative is returned by TryScalarPotential when the rotation of the field is not zero around the
+// reference point, so the field has no scalar potential
+var ErrNotConservative = errors.New("vcalc: field is not conservative")
+
+// Returns the scalar potential of vectorField, the scalar field phi with grad(phi) = F, which is 0 at the point ref
+// in the coordinates of vectorField
+// The potential is an expression when each component can be integrated symbolically, otherwise it calls a custom
+// function integrating the field along the straight line from ref, which must not pass a singular point, and the
+// parameters of the field must be bound before. Panics if the field is not conservative around ref
+func (v vectorField) ScalarPotential(ref []float64) scalarField {
+	phi, err := v.TryScalarPotential(ref)
+	if err != nil {
+		panic("The field is not conservative, its rotation is not zero around the reference point")
+	}
+	return phi
+}
+
+// Returns the scalar potential of vectorField like ScalarPotential
+// Returns ErrNotConservative instead of panicking when the field is not conservative around ref, which is checked
+// like ConservativeCheck at random points around ref unless the gradient of the symbolic potential is the field
+func (v vectorField) TryScalarPotential(ref []float64) (scalarField, error) {
+	if len(ref) != 3 {
+		panic("Too many or too few points coordinates given")
+	}
+	if res, ok := v.symbolicPotential(ref); ok {
+		at := map[string]*node{}
+		for i, name := range coordNames(v.coordsys) {
+			at[name] = num(ref[i])
+		}
+		res = op("-", res, res.substitute(at).simplify()).simplify()
+		return NewScalarField(res.String(), v.coordsys, withOptions(v.opts)), nil
+	}
+	if !v.conservativeAround(ref) {
+		return scalarField{}, ErrNotConservative
+	}
+	return v.pathPotential(ref), nil
+}
+
+// Returns true if the magnitude of the rotation of vectorField at ref and at random points around it is small
+// compared to the magnitude of the field there. Singular points are skipped
+func (v vectorField) conservativeAround(ref []float64) bool {
+	var rot, field float64
+	for _, c := range append(v.samples(ref, 100), ref) {
+		r, err := v.TryRot(c)
+		if err != nil {
+			continue
+		}
+		F := v.eval(c)
+		rot = math.Max(rot, math.Sqrt(r[0]*r[0]+r[1]*r[1]+r[2]*r[2]))
+		field = math.Max(field, math.Sqrt(F[0]*F[0]+F[1]*F[1]+F[2]*F[2]))
+	}
+	return rot <= 1e-6*math.Max(1, field)
+}
+
+// Returns true if the largest magnitude of the divergence of vectorField at random points of region is at most tol,
+// so the field is solenoidal there, and the largest magnitude, like ConservativeCheck
+func (v vectorField) SolenoidalCheck(g region, tol float64) (bool, float64) {
+	var largest float64
+	for _, c := range g.samples(100) {
+		div, err := v.TryDiv(g.in(c, v.coordsys))
+		if err != nil {
+			continue
+		}
+		largest = math.Max(largest, math.Abs(div))
+	}
+	return largest <= tol, largest
+}
+
+// ErrNotSolenoidal is returned by TryVectorPotential when the divergence of the field is not zero around the
+// center, so the field has no vector potential
+var ErrNotSolenoidal = errors.New("vcalc: field is not solenoidal")
+
+// Returns a vector potential of vectorField, the vector field A with rot(A) = B, in the Poincaré gauge about the
+// point center in the coordinates of vectorField, where A is perpendicular to the line from center to the point
+// Only this gauge is given, another potential is A plus the gradient of any scalar field
+// A is given by the homotopy formula, the integral of t*B(c+t*(x-c))×(x-c) from 0 to 1 with c the center, and each
+// component calls a custom function calculating it, so the parameters of the field must be bound first
+// The field must be solenoidal on the lines from center, panics if it is not solenoidal around center
+func (v vectorField) VectorPotential(center []float64) vectorField {
+	A, err := v.TryVectorPotential(center)
+	if err != nil {
+		panic("The field is not solenoidal, its divergence is not zero around the center")
+	}
+	return A
+}
+
+// Returns the vector potential of vectorField like VectorPotential
+// Returns ErrNotSolenoidal instead of panicking when the field is not solenoidal around center, which is checked
+// like SolenoidalCheck at random points around center
+func (v vectorField) TryVectorPotential(center []float64) (vectorField, error) {
+	if len(center) != 3 {
+		panic("Too many or too few points coordinates given")
+	}
+	if !v.solenoidalAround(center) {
+		return vectorField{}, ErrNotSolenoidal
+	}
+	o := v.opts.ownFuncs()
+	start := toCartesian(center, v.coordsys)
+	// The components are evaluated one after another at the same point, so the potential of the last point is
+	// kept and calculated once for all three
+	var mu sync.Mutex
+	var last, lastPotential []float64
+	// potential returns the components of A at the point c in the coordinates of vectorField
+	potential := func(c []float64) []float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		if reflect.DeepEqual(c, last) {
+			return lastPotential
+		}
+		p := toCartesian(c, v.coordsys)
+		d := []float64{p[0] - start[0], p[1] - start[1], p[2] - start[2]}
+		// The cross product with d is taken after integrating t*B, since d does not depend on t
+		b := make([]float64, 3)
+		for k := range b {
+			b[k], _ = integrate(func(t float64) float64 {
+				q := fromCartesian([]float64{start[0] + t*d[0], start[1] + t*d[1], start[2] + t*d[2]}, v.coordsys)
+				return t * vecToCartesian(v.eval(q), q, v.coordsys)[k]
+			}, 0, 1, newIntegralOptions(nil))
+		}
+		cross := []float64{b[1]*d[2] - b[2]*d[1], b[2]*d[0] - b[0]*d[2], b[0]*d[1] - b[1]*d[0]}
+		last, lastPotential = append([]float64(nil), c...), vecFromCartesian(cross, c, v.coordsys)
+		return lastPotential
+	}
+	coords := coordNames(v.coordsys)
+	args := []*node{variable(coords[0]), variable(coords[1]), variable(coords[2])}
+	e := make([]string, 3)
+	for i := range e {
+		i := i
+		name := o.funcs.unusedName("potential" + coords[i])
+		o.funcs.RegisterFunc(name, 3, func(c ...float64) float64 {
+			return potential(c)[i]
+		})
+		e[i] = o.funcs.call(name, args...).String()
+	}
+	return NewVectorField(e[0], e[1], e[2], v.coordsys, withOptions(o)), nil
+}
+
+// Returns true if the magnitude of the divergence of vectorField at center and at random points around it is
+// small compared to the magnitude of the field there, like conservativeAround. Singular points are skipped
+func (v vectorField) solenoidalAround(center []float64) bool {
+	var div, field float64
+	for _, c := range append(v.samples(center, 100), center) {
+		d, err := v.TryDiv(c)
+		if err != nil {
+			continue
+		}
+		F := v.eval(c)
+		div = math.Max(div, math.Abs(d))
+		field = math.Max(field, math.Sqrt(F[0]*F[0]+F[1]*F[1]+F[2]*F[2]))
+	}
+	return div <= 1e-6*math.Max(1, field)
+}
@@ -0,0 +1,169 @@
+package vcalc
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestScalarPotential(t *testing.T) {
+	var tests = []struct {
+		v        vectorField
+		ref      []float64
+		point    []float64
+		exp      float64
+		symbolic bool
+	}{
+		{NewVectorField("2*x*y", "x^2", "3", "car"), []float64{0, 0, 0}, []float64{1, 2, 3}, 11, true},
+		{NewVectorField("y*z*exp(x*y*z)", "x*z*exp(x*y*z)", "x*y*exp(x*y*z)", "car"), []float64{0, 0, 0}, []float64{1, 1, 1}, math.E - 1, true},
+		{NewVectorField("-1/r^2", "0", "0", "sph"), []float64{1, 1, 1}, []float64{2, 0.5, 3}, -0.5, true},
+		{NewVectorField("sin(z)", "0", "r*cos(z)", "cyl"), []float64{1, 0, 0}, []float64{2, 1, math.Pi / 2}, 2, true},
+		{NewVectorField("0", "1/r", "0", "cyl"), []float64{1, 0, 0}, []float64{3, 0.5, 1}, 0.5, true},
+		{NewVectorField("2*x*cos(x^2)", "0", "1", "car"), []float64{0, 0, 0}, []float64{1.5, 0, 2}, math.Sin(2.25) + 2, false},
+	}
+	for _, v := range tests {
+		phi := v.v.ScalarPotential(v.ref)
+		if got := phi.eval(v.point); math.Abs(got-v.exp) > 1e-7 {
+			t.Error("Test failed: {", v.v, v.point, " } inputted, expected {", v.exp, "} and got {", got, phi, "}")
+		}
+		if symbolic := !strings.Contains(phi.String(), "potential"); symbolic != v.symbolic {
+			t.Error("Test failed: {", v.v, " } inputted, expected a symbolic potential {", v.symbolic, "} and got {", phi, "}")
+		}
+		if got := phi.eval(v.ref); math.Abs(got) > 1e-12 {
+			t.Error("Test failed: {", v.v, v.ref, " } inputted, expected {", 0, "} and got {", got, "}")
+		}
+		// The gradient of the potential is the field
+		if got, exp := phi.Grad(v.point), v.v.eval(v.point); !almostEqual(got, exp, 1e-5) {
+			t.Error("Test failed: {", v.v, v.point, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+	// A parameter of a symbolic potential can be bound afterwards, and the potential calling a custom function
+	// can be differentiated
+	phi := NewVectorField("k*x", "0", "0", "car", WithParams("k")).ScalarPotential([]float64{0, 0, 0})
+	if got := phi.Bind("k", 4).eval([]float64{2, 0, 0}); math.Abs(got-8) > 1e-12 {
+		t.Error("Test failed: { k=4 } inputted, expected {", 8, "} and got {", got, phi, "}")
+	}
+	psi := NewVectorField("2*x*cos(x^2)", "0", "1", "car").ScalarPotential([]float64{0, 0, 0})
+	if got := psi.Diff("x").eval([]float64{1, 0, 0}); math.Abs(got-2*math.Cos(1)) > 1e-9 {
+		t.Error("Test failed: {", psi, " } inputted, expected {", 2*math.Cos(1), "} and got {", got, "}")
+	}
+}
+
+func TestTryScalarPotential(t *testing.T) {
+	var tests = []struct {
+		v   vectorField
+		exp error
+	}{
+		{NewVectorField("exp(-x^2)*y", "erf(x)*sqrt(pi)/2", "0", "car"), nil},
+		{NewVectorField("2*x*y", "x^2", "3", "car"), nil},
+		{NewVectorField("-y", "x", "0", "car"), ErrNotConservative},
+		{NewVectorField("0", "r", "0", "cyl"), ErrNotConservative},
+		{NewVectorField("exp(-x^2)*z", "0", "1", "car"), ErrNotConservative},
+	}
+	for _, v := range tests {
+		if _, err := v.v.TryScalarPotential([]float64{0.5, 0.5, 0.5}); err != v.exp {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, "} and got {", err, "}")
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Test failed: { (-y, x, 0) } inputted, expected a panic")
+		}
+	}()
+	NewVectorField("-y", "x", "0", "car").ScalarPotential([]float64{0, 0, 0})
+}
+
+func TestConservativeCheck(t *testing.T) {
+	box := NewBoxRegion([]float64{-1, -1, -1}, []float64{1, 1, 1})
+	var tests = []struct {
+		v       vectorField
+		g       region
+		exp     bool
+		largest float64
+	}{
+		{NewVectorField("2*x*y", "x^2", "3", "car"), box, true, 0},
+		{NewVectorField("-y", "x", "0", "car"), box, false, 2},
+		{NewVectorField("1/r^2", "0", "0", "sph"), NewSphericalSector(0.5, 2, 0, math.Pi, 0, 2*math.Pi), true, 0},
+		{NewVectorField("0", "r", "0", "cyl"), NewCylindricalShell(0, 1, 0, 2*math.Pi, 0, 1), false, 2},
+	}
+	for _, v := range tests {
+		if got, largest := v.v.ConservativeCheck(v.g, 1e-6); got != v.exp || math.Abs(largest-v.largest) > 1e-6 {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, v.largest, "} and got {", got, largest, "}")
+		}
+	}
+}
+
+func TestVectorPotential(t *testing.T) {
+	var tests = []struct {
+		v      vectorField
+		center []float64
+		points [][]float64
+	}{
+		{NewVectorField("0", "0", "1", "car"), []float64{0, 0, 0}, [][]float64{{1, 2, 3}, {-1, 0.5, 0}}},
+		{NewVectorField("x", "y", "-2*z", "car"), []float64{1, 0, 0}, [][]float64{{1, 2, 3}, {-1, 0.5, 0.2}}},
+		{NewVectorField("0", "0", "r^2", "cyl"), []float64{1, 0, 0}, [][]float64{{1, 0.5, 2}, {2, 2, -1}}},
+		// The field of a dipole, with the center away from the dipole at the origin
+		{NewVectorField("2*cos(theta)/r^3", "sin(theta)/r^3", "0", "sph"), []float64{2, 0.5, 0}, [][]float64{{1.5, 0.7, 0.3}, {2, 1, 1}}},
+	}
+	for _, v := range tests {
+		A := v.v.VectorPotential(v.center)
+		c := toCartesian(v.center, v.v.coordsys)
+		for _, p := range v.points {
+			if got, exp := A.Rot(p), v.v.eval(p); !almostEqual(got, exp, 1e-5) {
+				t.Error("Test failed: {", v.v, p, " } inputted, expected {", exp, "} and got {", got, "}")
+			}
+			// In the Poincaré gauge the potential is perpendicular to the line from the center
+			q := toCartesian(p, v.v.coordsys)
+			a := vecToCartesian(A.eval(p), p, v.v.coordsys)
+			if dot := a[0]*(q[0]-c[0]) + a[1]*(q[1]-c[1]) + a[2]*(q[2]-c[2]); math.Abs(dot) > 1e-9 {
+				t.Error("Test failed: {", v.v, p, " } inputted, expected {", 0, "} and got {", dot, "}")
+			}
+		}
+	}
+	// The potential of a uniform field about the origin is half the field crossed with the position
+	A := NewVectorField("0", "0", "B", "car", WithParams("B")).Bind("B", 2).VectorPotential([]float64{0, 0, 0})
+	if got, exp := A.eval([]float64{1, 2, 3}), []float64{-2, 1, 0}; !almostEqual(got, exp, 1e-12) {
+		t.Error("Test failed: { B=2 } inputted, expected {", exp, "} and got {", got, A, "}")
+	}
+}
+
+func TestTryVectorPotential(t *testing.T) {
+	var tests = []struct {
+		v   vectorField
+		exp error
+	}{
+		{NewVectorField("x", "y", "-2*z", "car"), nil},
+		{NewVectorField("2*cos(theta)/r^3", "sin(theta)/r^3", "0", "sph"), nil},
+		{NewVectorField("x", "y", "z", "car"), ErrNotSolenoidal},
+		{NewVectorField("r", "0", "0", "cyl"), ErrNotSolenoidal},
+	}
+	for _, v := range tests {
+		if _, err := v.v.TryVectorPotential([]float64{2, 0.5, 0.5}); err != v.exp {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, "} and got {", err, "}")
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Test failed: { (x, y, z) } inputted, expected a panic")
+		}
+	}()
+	NewVectorField("x", "y", "z", "car").VectorPotential([]float64{0, 0, 0})
+}
+
+func TestSolenoidalCheck(t *testing.T) {
+	var tests = []struct {
+		v       vectorField
+		g       region
+		exp     bool
+		largest float64
+	}{
+		{NewVectorField("x", "y", "-2*z", "car"), NewBoxRegion([]float64{-1, -1, -1}, []float64{1, 1, 1}), true, 0},
+		{NewVectorField("x", "y", "z", "car"), NewBoxRegion([]float64{-1, -1, -1}, []float64{1, 1, 1}), false, 3},
+		{NewVectorField("2*cos(theta)/r^3", "sin(theta)/r^3", "0", "sph"), NewSphericalSector(0.5, 2, 0, math.Pi, 0, 2*math.Pi), true, 0},
+	}
+	for _, v := range tests {
+		if got, largest := v.v.SolenoidalCheck(v.g, 1e-4); got != v.exp || math.Abs(largest-v.largest) > 1e-4 {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, v.largest, "} and got {", got, largest, "}")
+		}
+	}
+}
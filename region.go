@@ -0,0 +1,232 @@
+package vcalc
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// ErrMonteCarlo is returned by TryIntegrate with an integral calculated with Monte Carlo integration, whose error is
+// a statistical estimate which is not held to the tolerance of the integral
+var ErrMonteCarlo = errors.New("vcalc: integral calculated with Monte Carlo integration, the tolerance is not reached")
+
+// A region is the part of space between the limits lower and upper of each coordinate of its coordinate system,
+// "car" for a box, "cyl" for a cylindrical shell and "sph" for a spherical sector
+// A region with the parsed indicator expression only contains the points where the indicator is not 0
+type region struct {
+	coordsys     string
+	lower, upper []float64
+	indicator    *node
+	opts         fieldOptions
+}
+
+// Returns the box with the sides along the cartesian axes from the corner lower to the corner upper
+func NewBoxRegion(lower, upper []float64) region {
+	checkCenter(lower)
+	checkCenter(upper)
+	return region{coordsys: "car", lower: lower, upper: upper}
+}
+
+// Returns the cylindrical shell between the radii r0 and r1, the angles phi0 and phi1 and the heights z0 and z1
+func NewCylindricalShell(r0, r1, phi0, phi1, z0, z1 float64) region {
+	return region{coordsys: "cyl", lower: []float64{r0, phi0, z0}, upper: []float64{r1, phi1, z1}}
+}
+
+// Returns the spherical sector between the radii r0 and r1, the polar angles theta0 and theta1 and the azimuthal
+// angles phi0 and phi1
+func NewSphericalSector(r0, r1, theta0, theta1, phi0, phi1 float64) region {
+	return region{coordsys: "sph", lower: []float64{r0, theta0, phi0}, upper: []float64{r1, theta1, phi1}}
+}
+
+// Returns the region of the points between the limits lower and upper of the coordinates of coordsys where the
+// expression indicator of the coordinates is not 0, like "x^2+y^2+z^2 < 1" in the box from -1 to 1
+// The options WithParams, WithFuncs and WithStrictSyntax apply, the parameters are bound with Bind or BindParams
+// Integrals over the region are always calculated with Monte Carlo integration, since the quadrature can not follow
+// its edge, and it has no boundary for the divergence theorem
+func NewRegion(indicator, coordsys string, lower, upper []float64, opts ...FieldOption) region {
+	checkSurfaceCoords(coordsys)
+	checkCenter(lower)
+	checkCenter(upper)
+	o := newFieldOptions(opts)
+	checkCoords(indicator, coordsys, o)
+	g := region{coordsys: coordsys, lower: lower, upper: upper, opts: o}
+	if indicator != "" {
+		g.indicator = o.mustParse(indicator)
+	}
+	return g
+}
+
+// Returns a copy of region where the parameter name has the value value
+func (g region) Bind(name string, value float64) region {
+	return g.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of region where each parameter in values has its value
+func (g region) BindParams(values map[string]float64) region {
+	g.opts = g.opts.bind(values)
+	return g
+}
+
+// Returns the closed surface around region with its normal pointing outwards, made of the six faces where one
+// coordinate is at its lower or upper limit. Panics for a region given by an indicator, whose boundary is not known
+// A face where the coordinates meet at a point, like r = 0, has the normal 0 and adds nothing to a flux
+func (g region) Boundary() surface {
+	if g.indicator != nil {
+		panic("The boundary of a region given by an indicator is not known")
+	}
+	s := surface{}
+	for k := 0; k < 3; k++ {
+		// The unit vectors of the coordinates i, j, k are in cyclic order like in NewBox, so the derivative along i
+		// crossed with the derivative along j points along k
+		i, j := (k+1)%3, (k+2)%3
+		// face returns the point with the coordinate k at value and the coordinates a and b of the patch
+		face := func(value float64, a, b int) func(u, v float64) []float64 {
+			return func(u, v float64) []float64 {
+				c := make([]float64, 3)
+				c[k], c[a], c[b] = value, u, v
+				return c
+			}
+		}
+		s.patches = append(s.patches,
+			patch{f: face(g.upper[k], i, j), coordsys: g.coordsys, u: [2]float64{g.lower[i], g.upper[i]}, v: [2]float64{g.lower[j], g.upper[j]}},
+			patch{f: face(g.lower[k], j, i), coordsys: g.coordsys, u: [2]float64{g.lower[j], g.upper[j]}, v: [2]float64{g.lower[i], g.upper[i]}})
+	}
+	return s
+}
+
+// Returns true if the point c in the coordinates of region is inside it
+func (g region) inside(c []float64) bool {
+	if g.indicator == nil {
+		return true
+	}
+	c, coords := g.opts.withTime(c, coordNames(g.coordsys))
+	v := g.opts.fnN(c, g.indicator, coords)
+	return v != 0 && !math.IsNaN(v)
+}
+
+// Returns the jacobian of the coordinates of region at point c, the volume per volume of the coordinates
+func (g region) jacobian(c []float64) float64 {
+	switch g.coordsys {
+	case "cyl":
+		return c[0]
+	case "sph":
+		return c[0] * c[0] * math.Sin(c[1])
+	default:
+		return 1
+	}
+}
+
+// Returns the coordinates in coordsys of the point c in the coordinates of region
+func (g region) in(c []float64, coordsys string) []float64 {
+	if g.coordsys == coordsys {
+		return c
+	}
+	return fromCartesian(toCartesian(c, g.coordsys), coordsys)
+}
+
+// Returns the integral of f over region, where f takes a point in coordsys, an estimate of the error of the
+// integral, calculated with nested adaptive quadrature over the coordinates of region, and false
+// The integral over a region given by an indicator, whose edge the quadrature can not follow, is calculated with
+// Monte Carlo integration, and so is any integral whose integrand is evaluated more often than the most evaluations
+// allowed, like an integrand with a sharp peak. The quadrature stops as soon as that happens. The last value is
+// true for an integral from Monte Carlo integration
+func volumeIntegral(f func([]float64) float64, coordsys string, g region, opts []IntegralOption) (float64, float64, bool) {
+	o := newIntegralOptions(opts)
+	integrand := func(c []float64) float64 {
+		if !g.inside(c) {
+			return 0
+		}
+		return f(g.in(c, coordsys)) * g.jacobian(c)
+	}
+	if g.indicator == nil {
+		if res, err, ok := nestedIntegral(integrand, g, o); ok {
+			return res, err, false
+		}
+	}
+	res, err := monteCarlo(integrand, g.lower, g.upper, o.samples)
+	return res, err, true
+}
+
+// Returns the integral of integrand over the coordinates of region with nested adaptive quadrature, an estimate of
+// its error and true, or false if integrand is evaluated more often than the most evaluations allowed
+func nestedIntegral(integrand func([]float64) float64, g region, o integralOptions) (float64, float64, bool) {
+	evals := 0
+	// maxErr[k] is the largest error estimate of the integrals over the coordinate k
+	maxErr := make([]float64, 3)
+	var nested func(c []float64, k int) float64
+	nested = func(c []float64, k int) float64 {
+		if evals >= o.maxEvals {
+			// Once the integrand may not be evaluated again every integral is 0, so the quadrature ends quickly
+			evals = o.maxEvals + 1
+			return 0
+		}
+		if k == len(c) {
+			evals++
+			return integrand(c)
+		}
+		res, err := integrate(func(x float64) float64 {
+			p := append([]float64(nil), c...)
+			p[k] = x
+			return nested(p, k+1)
+		}, g.lower[k], g.upper[k], o)
+		maxErr[k] = math.Max(maxErr[k], err)
+		return res
+	}
+	res := nested(make([]float64, 3), 0)
+	if evals > o.maxEvals {
+		return 0, 0, false
+	}
+	// The integral of an error of an inner integral is at most its largest error times the length of the outer ones
+	err, length := maxErr[0], 1.0
+	for k := 1; k < 3; k++ {
+		length *= math.Abs(g.upper[k-1] - g.lower[k-1])
+		err += length * maxErr[k]
+	}
+	return res, err, true
+}
+
+// Returns the integral of f over the box from lower to upper calculated as the mean of f at n random points times
+// the volume of the box, and the standard error of the mean times the volume as an estimate of its error
+// The random points are the same for every call, so that an integral gives the same value each time
+func monteCarlo(f func([]float64) float64, lower, upper []float64, n int) (float64, float64) {
+	random := rand.New(rand.NewSource(1))
+	volume := 1.0
+	for i := range lower {
+		volume *= upper[i] - lower[i]
+	}
+	var sum, sumsq float64
+	c := make([]float64, len(lower))
+	for i := 0; i < n; i++ {
+		for k := range c {
+			c[k] = lower[k] + random.Float64()*(upper[k]-lower[k])
+		}
+		v := f(c)
+		sum += v
+		sumsq += v * v
+	}
+	mean := sum / float64(n)
+	variance := (sumsq/float64(n) - mean*mean) * float64(n) / float64(n-1)
+	return volume * mean, math.Abs(volume) * math.Sqrt(math.Max(variance, 0)/float64(n))
+}
+
+// Calculates the integral of scalarField over region with nested adaptive quadrature in the coordinates of region,
+// including the jacobian r of cylinder and r^2*sin(theta) of spherical coordinates
+// A region given by an indicator is always integrated with Monte Carlo integration, and so is any region where the
+// quadrature needs more evaluations than WithMaxEvals allows. Such an integral does not reach the tolerance of
+// WithTolerance, use TryIntegrate to find out
+// Returns the integral and an estimate of its error. The region can be in another coordinate system than the field
+func (s scalarField) Integrate(g region, opts ...IntegralOption) (float64, float64) {
+	res, err, _ := volumeIntegral(s.eval, s.coordsys, g, opts)
+	return res, err
+}
+
+// Calculates the integral of scalarField over region like Integrate
+// Returns the integral and an estimate of its error, with the error ErrMonteCarlo if they are from Monte Carlo
+// integration, where the estimate is the standard error and the tolerance of WithTolerance is not reached
+func (s scalarField) TryIntegrate(g region, opts ...IntegralOption) (float64, float64, error) {
+	res, err, mc := volumeIntegral(s.eval, s.coordsys, g, opts)
+	if mc {
+		return res, err, ErrMonteCarlo
+	}
+	return res, err, nil
+}
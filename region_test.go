@@ -0,0 +1,108 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegrateRegion(t *testing.T) {
+	var tests = []struct {
+		s   scalarField
+		g   region
+		exp float64
+	}{
+		{NewScalarField("x*y*z", "car"), NewBoxRegion([]float64{0, 0, 0}, []float64{1, 2, 3}), 4.5},
+		{NewScalarField("1", "cyl"), NewCylindricalShell(1, 2, 0, 2*math.Pi, 0, 1), 3 * math.Pi},
+		{NewScalarField("1", "sph"), NewSphericalSector(0, 1, 0, math.Pi, 0, 2*math.Pi), 4 * math.Pi / 3},
+		// The field and the region in different coordinate systems
+		{NewScalarField("z", "car"), NewSphericalSector(0, 1, 0, math.Pi/2, 0, 2*math.Pi), math.Pi / 4},
+		{NewScalarField("r^2", "sph"), NewBoxRegion([]float64{-1, -1, -1}, []float64{1, 1, 1}), 8},
+		{NewScalarField("x^2", "car"), NewCylindricalShell(0, 1, 0, math.Pi, -1, 1), math.Pi / 4},
+		{NewScalarField("exp(-r^2)", "sph"), NewSphericalSector(0, 10, 0, math.Pi, 0, 2*math.Pi), math.Pow(math.Pi, 1.5)},
+	}
+	for _, v := range tests {
+		if got, err, e := v.s.TryIntegrate(v.g); math.Abs(got-v.exp) > 1e-6 || err > 1e-6 || e != nil {
+			t.Error("Test failed: {", v.s, v.g.coordsys, " } inputted, expected {", v.exp, "} and got {", got, err, e, "}")
+		}
+	}
+}
+
+func TestIntegrateMonteCarlo(t *testing.T) {
+	ball := NewRegion("x^2+y^2+z^2 < 1", "car", []float64{-1, -1, -1}, []float64{1, 1, 1})
+	cylinder := NewRegion("r < R", "cyl", []float64{0, 0, 0}, []float64{2, 2 * math.Pi, 1}, WithParams("R")).Bind("R", 1)
+	var tests = []struct {
+		s    scalarField
+		g    region
+		opts []IntegralOption
+		exp  float64
+	}{
+		{NewScalarField("1", "car"), ball, []IntegralOption{WithSamples(20000)}, 4 * math.Pi / 3},
+		{NewScalarField("z^2", "car"), cylinder, []IntegralOption{WithSamples(20000)}, math.Pi / 3},
+		{NewScalarField("x*y*z", "car"), NewBoxRegion([]float64{0, 0, 0}, []float64{1, 2, 3}), []IntegralOption{WithMaxEvals(0), WithSamples(20000)}, 4.5},
+	}
+	for _, v := range tests {
+		got, err, e := v.s.TryIntegrate(v.g, v.opts...)
+		// Monte Carlo integration is within a few standard errors of the integral
+		if math.Abs(got-v.exp) > 4*err || err > 0.05*v.exp || e != ErrMonteCarlo {
+			t.Error("Test failed: {", v.s, v.g.indicator, " } inputted, expected {", v.exp, ErrMonteCarlo, "} and got {", got, err, e, "}")
+		}
+		if again, _ := v.s.Integrate(v.g, v.opts...); again != got {
+			t.Error("Test failed: {", v.s, v.g.indicator, " } inputted, expected {", got, "} and got {", again, "}")
+		}
+	}
+}
+
+func TestVolumeIntegralEvals(t *testing.T) {
+	var tests = []struct {
+		name string
+		g    region
+		opts []IntegralOption
+		max  int
+	}{
+		// A region given by an indicator is integrated at the random points only
+		{"indicator", NewRegion("x^2+y^2+z^2 < 1", "car", []float64{-1, -1, -1}, []float64{1, 1, 1}), []IntegralOption{WithSamples(500)}, 500},
+		// The quadrature stops at the most evaluations allowed and the random points follow
+		{"max evals", NewBoxRegion([]float64{0, 0, 0}, []float64{1, 1, 1}), []IntegralOption{WithMaxEvals(50), WithSamples(500)}, 550},
+	}
+	for _, v := range tests {
+		evals := 0
+		volumeIntegral(func(c []float64) float64 {
+			evals++
+			return math.Exp(-100 * (c[0]*c[0] + c[1]*c[1] + c[2]*c[2]))
+		}, "car", v.g, v.opts)
+		if evals > v.max {
+			t.Error("Test failed: {", v.name, " } inputted, expected at most {", v.max, "} evaluations and got {", evals, "}")
+		}
+	}
+	// A panic of the integrand is not taken for too many evaluations
+	defer func() {
+		if recover() == nil {
+			t.Error("Test failed: { panicking integrand } inputted, expected a panic")
+		}
+	}()
+	volumeIntegral(func(c []float64) float64 {
+		panic("integrand")
+	}, "car", NewBoxRegion([]float64{0, 0, 0}, []float64{1, 1, 1}), nil)
+}
+
+func TestRegionErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"undeclared name", func() { NewRegion("x < a", "car", []float64{0, 0, 0}, []float64{1, 1, 1}) }},
+		{"two-dimensional system", func() { NewRegion("r < 1", "polar", []float64{0, 0, 0}, []float64{1, 1, 1}) }},
+		{"corner", func() { NewBoxRegion([]float64{0, 0}, []float64{1, 1, 1}) }},
+		{"samples", func() { WithSamples(1)(&integralOptions{}) }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
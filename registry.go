@@ -0,0 +1,90 @@
+package vcalc
+
+// A registry of custom functions which can be called in the expressions of the fields it is given to
+// with WithFuncs, in addition to the built-in functions
+type funcRegistry struct {
+	funcs map[string]*function
+}
+
+// Returns a new registry without any custom functions
+func NewFuncRegistry() funcRegistry {
+	return funcRegistry{funcs: map[string]*function{}}
+}
+
+// Registers the function f called name taking arity arguments, or at least -arity arguments if arity is negative
+// The optional deriv are the names of the partial derivatives of f with respect to each argument, which are
+// functions called with the same arguments as f, so they must be registered before f or be f itself.
+// Without them a field calling name can not be differentiated
+// Panics if name is not a valid name or already names a function or coordinate, or if a derivative is unknown
+func (r funcRegistry) RegisterFunc(name string, arity int, f func(args ...float64) float64, deriv ...string) {
+	if r.funcs == nil {
+		panic("The registry is not created, create it with NewFuncRegistry")
+	}
+	if !identifier.MatchString(name) {
+		panic("Function name " + name + " is invalid, names are letters optionally followed by digits")
+	}
+	if r.isFUNC(name) {
+		panic("Function " + name + " is already defined")
+	}
+	if isConst(name) {
+		panic("Function name " + name + " is the name of a constant")
+	}
+	for _, coord := range defaultCoords {
+		if name == coord {
+			panic("Function name " + name + " is the name of a coordinate")
+		}
+	}
+	if name == timeName {
+		panic("Function name " + name + " is the name of the time")
+	}
+	if len(deriv) > 0 && len(deriv) != arity {
+		panic("Function " + name + " needs one derivative for each of its arguments")
+	}
+	for _, d := range deriv {
+		if d == name {
+			continue
+		}
+		f, ok := r.lookup(d)
+		if !ok {
+			panic("Derivative " + d + " of " + name + " is not a known function, register it before " + name)
+		}
+		if (f.arity >= 0 && f.arity != arity) || (f.arity < 0 && arity < -f.arity) {
+			panic("Derivative " + d + " of " + name + " can not be called with the arguments of " + name)
+		}
+	}
+	g := &function{arity: arity, eval: f}
+	if len(deriv) > 0 {
+		g.deriv = func(args []*node) []*node {
+			partials := make([]*node, len(deriv))
+			for i, d := range deriv {
+				partials[i] = r.call(d, args...)
+			}
+			return partials
+		}
+	}
+	r.funcs[name] = g
+}
+
+// Returns the function called name, which is a built-in function or one in the registry
+func (r funcRegistry) lookup(name string) (function, bool) {
+	if f, ok := functions[name]; ok {
+		return f, true
+	}
+	if f, ok := r.funcs[name]; ok {
+		return *f, true
+	}
+	return function{}, false
+}
+
+// Returns true if name is a built-in function or one in the registry
+func (r funcRegistry) isFUNC(name string) bool {
+	_, ok := r.lookup(name)
+	return ok
+}
+
+// Returns a new node calling the built-in or registered function name with args
+func (r funcRegistry) call(name string, args ...*node) *node {
+	n := call(name, args...)
+	n.fn = r.funcs[name]
+	return n
+}
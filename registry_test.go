@@ -0,0 +1,114 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns a registry with a smooth window, its derivative and a variadic sum of squares
+func testRegistry() funcRegistry {
+	r := NewFuncRegistry()
+	r.RegisterFunc("dwindow", 1, func(args ...float64) float64 {
+		return -2 * args[0] * math.Exp(-args[0]*args[0])
+	})
+	r.RegisterFunc("window", 1, func(args ...float64) float64 {
+		return math.Exp(-args[0] * args[0])
+	}, "dwindow")
+	r.RegisterFunc("sumsq", -1, func(args ...float64) float64 {
+		var sum float64
+		for _, v := range args {
+			sum += v * v
+		}
+		return sum
+	})
+	r.RegisterFunc("first", 2, func(args ...float64) float64 {
+		return args[0]
+	})
+	r.RegisterFunc("second", 2, func(args ...float64) float64 {
+		return args[1]
+	})
+	r.RegisterFunc("response", 2, func(args ...float64) float64 {
+		return args[0] * args[1]
+	}, "second", "first")
+	return r
+}
+
+func TestRegisterFunc(t *testing.T) {
+	r := testRegistry()
+	var tests = []struct {
+		point []float64
+		s     scalarField
+		exp   float64
+	}{
+		{[]float64{1, 2, 3}, NewScalarField("3window(x)+y", "car", WithFuncs(r)), 3*math.Exp(-1) + 2},
+		{[]float64{1, 2, 3}, NewScalarField("sumsq(x, y, z)", "car", WithFuncs(r)), 14},
+		{[]float64{2, 1, 0}, NewScalarField("response(r, a)", "sph", WithFuncs(r), WithParams("a")).Bind("a", 5), 10},
+	}
+	for _, v := range tests {
+		if exp := v.s.fn(v.point[0], v.point[1], v.point[2]); math.Abs(exp-v.exp) > 1e-12 {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestDiffRegisteredFunc(t *testing.T) {
+	r := testRegistry()
+	var tests = []struct {
+		s     scalarField
+		coord string
+		exp   string
+	}{
+		{NewScalarField("window(x^2)", "car", WithFuncs(r)), "x", "dwindow(x^2)*(2*x)"},
+		{NewScalarField("response(x, y)", "car", WithFuncs(r)), "y", "first(x, y)"},
+	}
+	for _, v := range tests {
+		if exp := v.s.Diff(v.coord).expression; exp != v.exp {
+			t.Error("Test failed: {", v.s, v.coord, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+	s := NewScalarField("window(x)*y", "car", WithFuncs(r))
+	if exp, grad := s.Diff("x").fn(0.5, 2, 0), s.Grad([]float64{0.5, 2, 0}); math.Abs(exp-grad[0]) > 1e-6 {
+		t.Error("Test failed: {", s, " } inputted, expected {", grad[0], "} and got {", exp, "}")
+	}
+}
+
+// Returns the sum of args
+func sumOf(args ...float64) float64 {
+	var sum float64
+	for _, v := range args {
+		sum += v
+	}
+	return sum
+}
+
+func TestRegisterFuncPanics(t *testing.T) {
+	r := testRegistry()
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"unregistered function", func() { NewScalarField("window(x)", "car") }},
+		{"other registry", func() { NewScalarField("window(x)", "car", WithFuncs(NewFuncRegistry())) }},
+		{"wrong arity", func() { NewScalarField("window(x, y)", "car", WithFuncs(r)) }},
+		{"built-in name", func() { r.RegisterFunc("sin", 1, sumOf) }},
+		{"registered name", func() { r.RegisterFunc("window", 1, sumOf) }},
+		{"coordinate name", func() { r.RegisterFunc("theta", 1, sumOf) }},
+		{"invalid name", func() { r.RegisterFunc("2f", 1, sumOf) }},
+		{"derivative count", func() { r.RegisterFunc("f", 2, sumOf, "g") }},
+		{"unknown derivative", func() { r.RegisterFunc("f", 1, sumOf, "df") }},
+		{"derivative arity", func() { r.RegisterFunc("f", 1, sumOf, "first") }},
+		{"parameter name", func() { NewScalarField("x", "car", WithFuncs(r), WithParams("window")) }},
+		{"no derivative", func() { NewScalarField("sumsq(x)", "car", WithFuncs(r)).Diff("x") }},
+		{"zero registry", func() { funcRegistry{}.RegisterFunc("f", 1, sumOf) }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic and got none")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
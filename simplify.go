@@ -0,0 +1,309 @@
+package vcalc
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// A term of a sum in a simplified expression, the coefficient times the product of the factors
+type term struct {
+	coef    float64
+	factors []factor
+}
+
+// A factor of a term, the base to the power exp where the base is not itself a product
+type factor struct {
+	base *node
+	exp  *node
+}
+
+// Returns the simplified tree of n, which has the same value as n wherever n is defined
+// Numbers are folded, sums and products are written as sums of terms with a coefficient where like terms
+// are collected, powers of the same base are merged and sin(u)^2+cos(u)^2 and cosh(u)^2-sinh(u)^2 are 1
+func (n *node) simplify() *node {
+	switch {
+	case n.kind == numNode || n.kind == varNode:
+		return n
+	case n.kind == callNode:
+		args := make([]*node, len(n.args))
+		for i, arg := range n.args {
+			args[i] = arg.simplify()
+		}
+		return foldCall(&node{kind: callNode, name: n.name, args: args, fn: n.fn})
+	case n.kind == opNode && isComparison(n.op):
+		return op(n.op, n.args[0].simplify(), n.args[1].simplify())
+	default:
+		return fromTerms(sumTerms(n))
+	}
+}
+
+// Returns n as a number if every argument of the call n is a number and its value is an integer, n if not
+// Other values are not folded so that a simplified expression like sqrt(2) stays exact and readable
+func foldCall(n *node) *node {
+	for _, arg := range n.args {
+		if arg.kind != numNode {
+			return n
+		}
+	}
+	if v := n.eval(nil, nil); isInteger(v) {
+		return num(v)
+	}
+	return n
+}
+
+// Returns true if v is a finite integer
+func isInteger(v float64) bool {
+	return v == math.Trunc(v) && !math.IsInf(v, 0)
+}
+
+// Returns the terms of the sum n, where like terms are collected
+func sumTerms(n *node) []term {
+	var terms []term
+	switch {
+	case n.kind == opNode && (n.op == "+" || n.op == "-"):
+		terms = sumTerms(n.args[0])
+		b := sumTerms(n.args[1])
+		if n.op == "-" {
+			for i := range b {
+				b[i].coef = -b[i].coef
+			}
+		}
+		terms = append(terms, b...)
+	case n.kind == negNode:
+		terms = sumTerms(n.args[0])
+		for i := range terms {
+			terms[i].coef = -terms[i].coef
+		}
+	default:
+		terms = []term{productTerm(n)}
+	}
+	return collect(terms)
+}
+
+// Returns n as one term, the coefficient times the product of its factors
+func productTerm(n *node) term {
+	switch {
+	case n.kind == numNode:
+		return term{coef: n.value}
+	case n.kind == negNode:
+		t := productTerm(n.args[0])
+		t.coef = -t.coef
+		return t
+	case n.kind == opNode && n.op == "*":
+		return mul(productTerm(n.args[0]), productTerm(n.args[1]))
+	case n.kind == opNode && n.op == "/":
+		b := productTerm(n.args[1])
+		if b.coef == 0 {
+			return divisionByZero(n)
+		}
+		return mul(productTerm(n.args[0]), b.pow(num(-1)))
+	case n.kind == opNode && n.op == "^":
+		exp := n.args[1].simplify()
+		base := n.args[0].simplify()
+		if exp.kind == numNode && isInteger(exp.value) && !isSum(base) {
+			// An integer power of a product is the product of the powers of its factors
+			b := productTerm(base)
+			if b.coef == 0 && exp.value < 0 {
+				return divisionByZero(n)
+			}
+			return b.pow(exp)
+		}
+		if base.kind == numNode && exp.kind == numNode {
+			if v := math.Pow(base.value, exp.value); isInteger(v) {
+				return term{coef: v}
+			}
+		}
+		if exp.is(0) {
+			return term{coef: 1}
+		}
+		return term{coef: 1, factors: []factor{{base, exp}}}
+	case isSum(n):
+		s := n.simplify()
+		if !isSum(s) {
+			return productTerm(s)
+		}
+		return term{coef: 1, factors: []factor{{s, num(1)}}}
+	default:
+		s := n.simplify()
+		if s.kind == numNode {
+			return term{coef: s.value}
+		}
+		return term{coef: 1, factors: []factor{{s, num(1)}}}
+	}
+}
+
+// Returns the division by zero n as a term with n as its only factor, so that it is kept as it is written
+func divisionByZero(n *node) term {
+	a, b := n.args[0].simplify(), n.args[1].simplify()
+	return term{coef: 1, factors: []factor{{&node{kind: opNode, op: n.op, args: []*node{a, b}}, num(1)}}}
+}
+
+// Returns true if n is a sum or difference
+func isSum(n *node) bool {
+	return n.kind == opNode && (n.op == "+" || n.op == "-")
+}
+
+// Returns the product of the terms a and b, where the powers of the same base are merged
+func mul(a, b term) term {
+	res := term{coef: a.coef * b.coef, factors: append([]factor(nil), a.factors...)}
+	for _, f := range b.factors {
+		merged := false
+		for i, g := range res.factors {
+			if f.base.String() == g.base.String() {
+				res.factors[i].exp = op("+", g.exp, f.exp).simplify()
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			res.factors = append(res.factors, f)
+		}
+	}
+	// Factors whose exponents cancel are removed
+	factors := res.factors[:0]
+	for _, f := range res.factors {
+		if !f.exp.is(0) {
+			factors = append(factors, f)
+		}
+	}
+	res.factors = factors
+	return res
+}
+
+// Returns the term t to the power exp, which is an integer number or -1
+func (t term) pow(exp *node) term {
+	res := term{coef: math.Pow(t.coef, exp.value)}
+	for _, f := range t.factors {
+		if e := op("*", f.exp, exp).simplify(); !e.is(0) {
+			res.factors = append(res.factors, factor{f.base, e})
+		}
+	}
+	return res
+}
+
+// Returns the factors of t as a key which is the same for like terms
+func (t term) key() string {
+	keys := make([]string, len(t.factors))
+	for i, f := range t.factors {
+		keys[i] = f.base.String() + "^" + f.exp.String()
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "*")
+}
+
+// Returns the terms where like terms are added and terms with the coefficient zero are removed,
+// each term is kept at the place it is first found
+func collect(terms []term) []term {
+	for {
+		var res []term
+		index := map[string]int{}
+		for _, t := range terms {
+			if i, ok := index[t.key()]; ok {
+				res[i].coef += t.coef
+				continue
+			}
+			index[t.key()] = len(res)
+			res = append(res, t)
+		}
+		terms = res[:0]
+		for _, t := range res {
+			if t.coef != 0 {
+				terms = append(terms, t)
+			}
+		}
+		if !pythagoras(terms) {
+			return terms
+		}
+	}
+}
+
+// Replaces a pair of terms c*sin(u)^2*rest and c*cos(u)^2*rest by c*rest, and likewise c*cosh(u)^2*rest and
+// -c*sinh(u)^2*rest, the rest being the same in both terms
+// Returns true if a pair was replaced, in which case the terms need to be collected again
+func pythagoras(terms []term) bool {
+	pairs := []struct {
+		a, b string
+		sign float64
+	}{{"sin", "cos", 1}, {"cosh", "sinh", -1}}
+	for i, t := range terms {
+		for _, p := range pairs {
+			for _, a := range t.without(p.a) {
+				for j, s := range terms {
+					if i == j || s.coef != p.sign*t.coef {
+						continue
+					}
+					for _, b := range s.without(p.b) {
+						if a.arg != b.arg || a.rest.key() != b.rest.key() {
+							continue
+						}
+						terms[i] = a.rest
+						terms[j].coef = 0
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// A term without one of its factors name(arg)^2
+type square struct {
+	arg  string
+	rest term
+}
+
+// Returns for each factor name(u)^2 of t the argument u as string and t without that factor
+func (t term) without(name string) []square {
+	var res []square
+	for i, f := range t.factors {
+		if f.base.kind == callNode && f.base.name == name && f.exp.is(2) {
+			rest := term{coef: t.coef, factors: append(append([]factor(nil), t.factors[:i]...), t.factors[i+1:]...)}
+			res = append(res, square{f.base.args[0].String(), rest})
+		}
+	}
+	return res
+}
+
+// Returns the tree of the sum of the terms
+func fromTerms(terms []term) *node {
+	res := num(0)
+	for i, t := range terms {
+		if i > 0 && t.coef < 0 {
+			res = op("-", res, term{-t.coef, t.factors}.node())
+		} else {
+			res = op("+", res, t.node())
+		}
+	}
+	return res
+}
+
+// Returns the tree of the term t, where the factors with negative exponents form the denominator
+// and a coefficient like 2/3 is written as a division by 3
+func (t term) node() *node {
+	p, q := rational(t.coef)
+	numerator, denominator := num(p), num(q)
+	for _, f := range t.factors {
+		if f.exp.kind == numNode && f.exp.value < 0 {
+			denominator = op("*", denominator, op("^", f.base, num(-f.exp.value)))
+		} else {
+			numerator = op("*", numerator, op("^", f.base, f.exp))
+		}
+	}
+	return op("/", numerator, denominator)
+}
+
+// Returns the numerator and denominator of the fraction c when the denominator is at most 100, and c and 1 if not
+// The fraction must equal c up to a tolerance relative to c, so the numerator is never 0 for a c which is not 0
+func rational(c float64) (float64, float64) {
+	if c == 0 {
+		return 0, 1
+	}
+	for q := 1.0; q <= 100; q++ {
+		if p := math.Round(c * q); p != 0 && math.Abs(c*q-p) <= 1e-12*math.Abs(c*q) {
+			return p, q
+		}
+	}
+	return c, 1
+}
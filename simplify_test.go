@@ -0,0 +1,117 @@
+package vcalc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	var tests = []struct {
+		expression string
+		exp        string
+	}{
+		{"0*x + 1*y^1", "y"},
+		{"2*3+x*0.5", "6+x/2"},
+		{"x*x^2*x^-1", "x^2"},
+		{"x/x", "1"},
+		{"2x+3x-y+y", "5*x"},
+		{"x-(y-z)", "x-y+z"},
+		{"(x*y)^2/x", "x*y^2"},
+		{"2^x*2^y", "2^(x+y)"},
+		{"x^y*x", "x^(y+1)"},
+		{"(x+y)*(x+y)", "(x+y)^2"},
+		{"sin(x)^2+cos(x)^2", "1"},
+		{"sin(x)^2*cos(y)^2+sin(x)^2*sin(y)^2", "sin(x)^2"},
+		{"3sin(x*y)^2*z+3z*cos(x*y)^2+1", "3*z+1"},
+		{"cosh(x)^2-sinh(x)^2", "1"},
+		{"q/r^2*-sin(theta)", "-q*sin(theta)/r^2"},
+		{"x-2y/3", "x-2*y/3"},
+		{"if(x<1, 0*x+2, x^1)", "if(x<1, 2, x)"},
+		{"sqrt(4)+sqrt(2)+cos(0)", "3+sqrt(2)"},
+		{"(x^2)^0.5", "(x^2)^0.5"},
+		{"x/(y-y)", "x/0"},
+		{"x-x", "0"},
+	}
+	for _, v := range tests {
+		if exp := mustParse(v.expression).simplify().String(); exp != v.exp {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestSimplifyEquivalence(t *testing.T) {
+	// The simplified expressions are compared to the expressions at random points where they are defined
+	var tests = []string{
+		"0*x + 1*y^1", "x*x^2*x^-1/y", "(x*y)^2/x-x*y^2+z", "2x+3x-y+y", "x-(y-z)-(-(x-y))", "2^x*2^y*4^z",
+		"x^y*x/x^(y-1)", "(x+y)*(x+y)*2", "sin(x)^2+cos(x)^2+tan(y)", "3sin(x*y)^2*z+3z*cos(x*y)^2",
+		"cosh(x)^2-sinh(x)^2-1", "q/r^2*-sin(theta)", "0.1x+0.2x-y/3", "exp(x)*exp(x)/exp(2x)", "x^0.5*x^0.5",
+		"if(x<0.5, x*x, -x/x)", "(2x^2*y)^3/(4x^6)", "1/(x+1)+2/(1+x)", "max(x, y)*min(x, y)/x", "-(-x)-(-(-y))",
+	}
+	coords := []string{"x", "y", "z", "q", "r", "theta"}
+	rnd := rand.New(rand.NewSource(1))
+	for _, v := range tests {
+		n := mustParse(v)
+		s := n.simplify()
+		for i := 0; i < 20; i++ {
+			c := make([]float64, len(coords))
+			for j := range c {
+				c[j] = 0.1 + 2*rnd.Float64()
+			}
+			a, b := n.eval(c, coords), s.eval(c, coords)
+			if math.Abs(a-b) > 1e-9*math.Max(1, math.Abs(a)) {
+				t.Error("Test failed: {", v, c, " } inputted, expected {", a, "} and got {", b, "} from {", s, "}")
+			}
+		}
+	}
+}
+
+func TestSimplifyScale(t *testing.T) {
+	// Tiny and huge coefficients are kept, the points are chosen so that every term matters
+	var tests = []struct {
+		expression string
+		c          []float64
+	}{
+		{"1e-15*x+y", []float64{1e15, 1}},
+		{"1e-20*x*y-2e-21*x", []float64{1e20, 3}},
+		{"x/3e-14+y", []float64{1e-14, 1}},
+		{"3e20*x+2e20*x-y", []float64{1, 5e20}},
+		{"1.5e300*x+y/7e-12", []float64{1, 1e289}},
+		{"0.1x+0.2x", []float64{1, 0}},
+	}
+	coords := []string{"x", "y"}
+	for _, v := range tests {
+		n := mustParse(v.expression)
+		s := n.simplify()
+		if a, b := n.eval(v.c, coords), s.eval(v.c, coords); math.Abs(a-b) > 1e-12*math.Abs(a) {
+			t.Error("Test failed: {", v.expression, v.c, " } inputted, expected {", a, "} and got {", b, "} from {", s, "}")
+		}
+	}
+}
+
+func TestFieldSimplify(t *testing.T) {
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{NewScalarField("q/r^2 cos(theta)", "sph", WithParams("q")).Diff("theta").Simplify().String(), "-q*sin(theta)/r^2"},
+		{NewScalarField("x^2*y", "car").Diff("x").Simplify().String(), "2*x*y"},
+		{NewVectorField("0*x", "y*y", "z/z", "car").Simplify().String(), "(0, y^2, 1)"},
+		{NewScalarField2D("r*r*cos(phi)^2+r^2*sin(phi)^2", "polar").Simplify().String(), "r^2"},
+		{NewVectorField2D("x+x", "y-y", "car2").Simplify().String(), "(2*x, 0)"},
+		{NewScalarFieldN("x1*x2/x1", []string{"x1", "x2"}).Simplify().String(), "x2"},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestFieldSimplifyEval(t *testing.T) {
+	// The simplified field calculates the simplified expression, which is defined where x1 is 0
+	s := NewScalarFieldN("x1*x2/x1", []string{"x1", "x2"}).Simplify()
+	if exp := s.fn([]float64{0, 3}); exp != 3 {
+		t.Error("Test failed: {", s, []float64{0, 3}, " } inputted, expected {", 3, "} and got {", exp, "}")
+	}
+}
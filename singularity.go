@@ -0,0 +1,171 @@
+package vcalc
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingularPoint is returned by TryGrad, TryDiv, TryRot and their two-dimensional counterparts when the point
+// is on the axis or at the origin of a curvilinear coordinate system and the field uses SingularityReject
+var ErrSingularPoint = errors.New("vcalc: point is a singular point of the coordinate system")
+
+// A SingularityStrategy decides how the differential operators of a field are calculated at the singular points
+// of its coordinate system, that is r = 0 in cylinder, spherical and polar coordinates and sin(theta) = 0 in
+// spherical coordinates
+type SingularityStrategy int
+
+const (
+	// SingularityReject makes Grad, Div, Rot, Curl and Laplacian panic and their Try versions return
+	// ErrSingularPoint, this is the default
+	SingularityReject SingularityStrategy = iota
+	// SingularityLimit evaluates the limit of the operator as the point is approached from a small offset
+	SingularityLimit
+	// SingularityCartesian calculates the operator in cartesian coordinates and converts the result back
+	// to the unit vectors of the coordinate system at the point
+	SingularityCartesian
+)
+
+// Returns true if theta is on the axis of spherical coordinates, with a tolerance since math.Sin(math.Pi) is not 0
+func onAxis(theta float64) bool {
+	return math.Abs(math.Sin(theta)) < 1e-12
+}
+
+// Returns true if c is a singular point of coordsys
+func singular(c []float64, coordsys string) bool {
+	switch coordsys {
+	case "cyl", "polar":
+		return c[0] == 0
+	case "sph":
+		return c[0] == 0 || onAxis(c[1])
+	default:
+		return false
+	}
+}
+
+// Returns the panic message for a singular point of coordsys
+func singularMsg(coordsys string) string {
+	if coordsys == "sph" {
+		return "r must be larger than zero and theta cannot be 0 or pi"
+	}
+	return "r must be larger than zero"
+}
+
+// Calculates the operator op at point c in coordsys, handling a singular point c according to strategy
+// cartesian calculates the operator at c through cartesian coordinates and is used by SingularityCartesian
+func atSingularity(c []float64, coordsys string, strategy SingularityStrategy, op, cartesian func([]float64) []float64) ([]float64, error) {
+	if !singular(c, coordsys) {
+		return op(c), nil
+	}
+	switch strategy {
+	case SingularityLimit:
+		return limit(c, coordsys, op), nil
+	case SingularityCartesian:
+		return cartesian(c), nil
+	default:
+		return nil, ErrSingularPoint
+	}
+}
+
+// Returns the limit of op as the singular point c is approached from inside the coordinate system
+// op is calculated at the offsets d and 2d and extrapolated to zero offset, making the error proportional to d^2
+func limit(c []float64, coordsys string, op func([]float64) []float64) []float64 {
+	d := 0.001
+	offset := func(d float64) []float64 {
+		p := append([]float64(nil), c...)
+		if p[0] == 0 {
+			p[0] = d
+		}
+		if coordsys == "sph" && onAxis(p[1]) {
+			// Move theta towards the inside of [0, pi]
+			if math.Cos(p[1]) > 0 {
+				p[1] += d
+			} else {
+				p[1] -= d
+			}
+		}
+		return p
+	}
+	near := op(offset(d))
+	far := op(offset(2 * d))
+	res := make([]float64, len(near))
+	for i := range res {
+		res[i] = 2*near[i] - far[i]
+	}
+	return res
+}
+
+// Returns the partial derivative of f with respect to the coordinate i at point p
+func partial(f func([]float64) float64, p []float64, i int) float64 {
+	h := 0.0001
+	return (f(shift(p, i, h)) - f(shift(p, i, -h))) / (2 * h)
+}
+
+// Returns the second partial derivative of f with respect to the coordinate i at point p
+func partial2(f func([]float64) float64, p []float64, i int) float64 {
+	h := 0.001
+	return (f(shift(p, i, h)) - 2*f(p) + f(shift(p, i, -h))) / (h * h)
+}
+
+// Returns the scalar field f, which takes a point in coordsys, as a function of cartesian coordinates
+func scalarCartesian(f func([]float64) float64, coordsys string) func([]float64) float64 {
+	return func(p []float64) float64 {
+		return f(fromCartesian(p, coordsys))
+	}
+}
+
+// Returns the cartesian component i of the vector field F, which takes a point in coordsys and returns components
+// in the unit vectors of coordsys, as a function of cartesian coordinates
+func vectorCartesian(F func([]float64) []float64, coordsys string, i int) func([]float64) float64 {
+	return func(p []float64) float64 {
+		q := fromCartesian(p, coordsys)
+		return vecToCartesian(F(q), q, coordsys)[i]
+	}
+}
+
+// Calculates the gradient of the scalar field f at point c through cartesian coordinates
+func gradCartesian(f func([]float64) float64, c []float64, coordsys string) []float64 {
+	g := scalarCartesian(f, coordsys)
+	p := toCartesian(c, coordsys)
+	grad := make([]float64, len(p))
+	for i := range p {
+		grad[i] = partial(g, p, i)
+	}
+	return vecFromCartesian(grad, c, coordsys)
+}
+
+// Calculates the laplacian of the scalar field f at point c through cartesian coordinates
+func laplacianCartesian(f func([]float64) float64, c []float64, coordsys string) float64 {
+	g := scalarCartesian(f, coordsys)
+	p := toCartesian(c, coordsys)
+	var laplacian float64
+	for i := range p {
+		laplacian += partial2(g, p, i)
+	}
+	return laplacian
+}
+
+// Calculates the divergence of the vector field F at point c through cartesian coordinates
+func divCartesian(F func([]float64) []float64, c []float64, coordsys string) float64 {
+	p := toCartesian(c, coordsys)
+	var div float64
+	for i := range p {
+		div += partial(vectorCartesian(F, coordsys, i), p, i)
+	}
+	return div
+}
+
+// Calculates the rotation of the vector field F at point c through cartesian coordinates
+// For two-dimensional fields the result is the scalar curl as the only element
+func rotCartesian(F func([]float64) []float64, c []float64, coordsys string) []float64 {
+	p := toCartesian(c, coordsys)
+	G := func(i int) func([]float64) float64 {
+		return vectorCartesian(F, coordsys, i)
+	}
+	if len(p) == 2 {
+		return []float64{partial(G(1), p, 0) - partial(G(0), p, 1)}
+	}
+	return vecFromCartesian([]float64{
+		partial(G(2), p, 1) - partial(G(1), p, 2),
+		partial(G(0), p, 2) - partial(G(2), p, 0),
+		partial(G(1), p, 0) - partial(G(0), p, 1)}, c, coordsys)
+}
@@ -0,0 +1,92 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTryGradSingular(t *testing.T) {
+	var tests = []struct {
+		point    []float64
+		s        scalarField
+		exp      []float64
+		expError error
+	}{
+		{[]float64{0, 0.3, 1}, NewScalarField("z*r^2+3z", "cyl"), nil, ErrSingularPoint},
+		{[]float64{0, 0.3, 1}, NewScalarField("z*r^2+3z", "cyl", WithSingularity(SingularityLimit)), []float64{0, 0, 3}, nil},
+		{[]float64{0, 0.3, 1}, NewScalarField("z*r^2+3z", "cyl", WithSingularity(SingularityCartesian)), []float64{0, 0, 3}, nil},
+		{[]float64{1, 0, 0}, NewScalarField("r^2", "sph", WithSingularity(SingularityLimit)), []float64{2, 0, 0}, nil},
+		{[]float64{1, 0, 0}, NewScalarField("r^2", "sph", WithSingularity(SingularityCartesian)), []float64{2, 0, 0}, nil},
+		{[]float64{1, math.Pi, 0}, NewScalarField("r^2", "sph"), nil, ErrSingularPoint},
+		{[]float64{1, math.Pi, 0}, NewScalarField("r^2", "sph", WithSingularity(SingularityLimit)), []float64{2, 0, 0}, nil},
+		{[]float64{1, math.Pi, 0}, NewScalarField("r^2", "sph", WithSingularity(SingularityCartesian)), []float64{2, 0, 0}, nil},
+		{[]float64{0, 0.5, 0}, NewScalarField("r*cos(theta)", "sph", WithSingularity(SingularityLimit)), []float64{math.Cos(0.5), -math.Sin(0.5), 0}, nil},
+		{[]float64{0, 0.5, 0}, NewScalarField("r*cos(theta)", "sph", WithSingularity(SingularityCartesian)), []float64{math.Cos(0.5), -math.Sin(0.5), 0}, nil},
+	}
+	for _, v := range tests {
+		if exp, err := v.s.TryGrad(v.point); err != v.expError || !almostEqual(exp, v.exp, 1e-5) {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, v.expError, "} and got {", exp, err, "}")
+		}
+	}
+}
+
+func TestTryDivSingular(t *testing.T) {
+	var tests = []struct {
+		point    []float64
+		v        vectorField
+		exp      float64
+		expError error
+	}{
+		{[]float64{0, 0, 0}, NewVectorField("r", "0", "0", "cyl"), 0, ErrSingularPoint},
+		{[]float64{0, 0, 0}, NewVectorField("r", "0", "0", "cyl", WithSingularity(SingularityLimit)), 2, nil},
+		{[]float64{0, 0, 0}, NewVectorField("r", "0", "0", "cyl", WithSingularity(SingularityCartesian)), 2, nil},
+		{[]float64{0, 1, 2}, NewVectorField("r", "0", "0", "sph", WithSingularity(SingularityLimit)), 3, nil},
+		{[]float64{0, 1, 2}, NewVectorField("r", "0", "0", "sph", WithSingularity(SingularityCartesian)), 3, nil},
+	}
+	for _, v := range tests {
+		if exp, err := v.v.TryDiv(v.point); err != v.expError || math.Abs(exp-v.exp) > 1e-5 {
+			t.Error("Test failed: {", v.point, v.v, " } inputted, expected {", v.exp, v.expError, "} and got {", exp, err, "}")
+		}
+	}
+}
+
+func TestTryRotSingular(t *testing.T) {
+	var tests = []struct {
+		point    []float64
+		v        vectorField
+		exp      []float64
+		expError error
+	}{
+		{[]float64{0, 0, 0}, NewVectorField("0", "r", "0", "cyl"), nil, ErrSingularPoint},
+		{[]float64{0, 0, 0}, NewVectorField("0", "r", "0", "cyl", WithSingularity(SingularityLimit)), []float64{0, 0, 2}, nil},
+		{[]float64{0, 0, 0}, NewVectorField("0", "r", "0", "cyl", WithSingularity(SingularityCartesian)), []float64{0, 0, 2}, nil},
+	}
+	for _, v := range tests {
+		if exp, err := v.v.TryRot(v.point); err != v.expError || !almostEqual(exp, v.exp, 1e-5) {
+			t.Error("Test failed: {", v.point, v.v, " } inputted, expected {", v.exp, v.expError, "} and got {", exp, err, "}")
+		}
+	}
+}
+
+func TestTry2DSingular(t *testing.T) {
+	var tests = []struct {
+		s   scalarField2D
+		v   vectorField2D
+		exp []float64
+	}{
+		{NewScalarField2D("r^2", "polar", WithSingularity(SingularityLimit)), NewVectorField2D("r", "r", "polar", WithSingularity(SingularityLimit)), []float64{4, 2, 2}},
+		{NewScalarField2D("r^2", "polar", WithSingularity(SingularityCartesian)), NewVectorField2D("r", "r", "polar", WithSingularity(SingularityCartesian)), []float64{4, 2, 2}},
+	}
+	for _, v := range tests {
+		point := []float64{0, 1}
+		laplacian, err1 := v.s.TryLaplacian(point)
+		div, err2 := v.v.TryDiv(point)
+		curl, err3 := v.v.TryCurl(point)
+		if exp := []float64{laplacian, div, curl}; err1 != nil || err2 != nil || err3 != nil || !almostEqual(exp, v.exp, 1e-4) {
+			t.Error("Test failed: {", point, v.s, v.v, " } inputted, expected {", v.exp, "} and got {", exp, err1, err2, err3, "}")
+		}
+	}
+	if _, err := NewScalarField2D("r^2", "polar").TryGrad([]float64{0, 1}); err != ErrSingularPoint {
+		t.Error("Test failed: {", []float64{0, 1}, " } inputted, expected {", ErrSingularPoint, "} and got {", err, "}")
+	}
+}
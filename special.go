@@ -0,0 +1,163 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// Returns the integer n holds, ok is false if n is not an integer
+func order(n float64) (int, bool) {
+	if n != math.Trunc(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// Returns the Bessel function of the first kind J_n(x) of integer order n, NaN if n is not an integer
+func besselJ(n, x float64) float64 {
+	if n, ok := order(n); ok {
+		return math.Jn(n, x)
+	}
+	return math.NaN()
+}
+
+// Returns the Bessel function of the second kind Y_n(x) of integer order n, NaN if n is not an integer
+func besselY(n, x float64) float64 {
+	if n, ok := order(n); ok {
+		return math.Yn(n, x)
+	}
+	return math.NaN()
+}
+
+// Returns the associated Legendre polynomial P_l^m(x) of integer degree l and order m, including the
+// Condon-Shortley phase (-1)^m, for -1 <= x <= 1
+// The polynomial is zero when |m| > l or l < 0 and NaN when l or m is not an integer or x is outside [-1, 1]
+func legendre(l, m, x float64) float64 {
+	L, ok1 := order(l)
+	M, ok2 := order(m)
+	if !ok1 || !ok2 || x < -1 || x > 1 {
+		return math.NaN()
+	}
+	if L < 0 || M > L || -M > L {
+		return 0
+	}
+	if M < 0 {
+		// P_l^-m = (-1)^m (l-m)!/(l+m)! P_l^m
+		return math.Pow(-1, float64(-M)) * factorialRatio(L+M, L-M) * legendre(l, -m, x)
+	}
+	// Recurrence from P_m^m up to P_l^m
+	pmm := 1.0
+	somx2 := math.Sqrt((1 - x) * (1 + x))
+	fact := 1.0
+	for i := 1; i <= M; i++ {
+		pmm *= -fact * somx2
+		fact += 2
+	}
+	if L == M {
+		return pmm
+	}
+	pmmp1 := x * float64(2*M+1) * pmm
+	for ll := M + 2; ll <= L; ll++ {
+		pll := (x*float64(2*ll-1)*pmmp1 - float64(ll+M-1)*pmm) / float64(ll-M)
+		pmm, pmmp1 = pmmp1, pll
+	}
+	return pmmp1
+}
+
+// Returns a!/b! for non-negative integers a and b
+func factorialRatio(a, b int) float64 {
+	res := 1.0
+	for i := a; i > b; i-- {
+		res *= float64(i)
+	}
+	for i := b; i > a; i-- {
+		res /= float64(i)
+	}
+	return res
+}
+
+// Returns the factor of the real spherical harmonic of degree l and order m multiplying the associated
+// Legendre polynomial P_l^|m|(cos theta) and cos(m phi) or sin(|m| phi)
+// The factor cancels the Condon-Shortley phase of legendre so that the harmonics are real and orthonormal
+func ylmFactor(l, m int) float64 {
+	if m < 0 {
+		m = -m
+	}
+	k := math.Sqrt(float64(2*l+1) / (4 * math.Pi) * factorialRatio(l-m, l+m))
+	if m != 0 {
+		k *= math.Sqrt2 * math.Pow(-1, float64(m))
+	}
+	return k
+}
+
+// Returns the real spherical harmonic Y_lm(theta, phi) of integer degree l >= 0 and order -l <= m <= l,
+// which is NaN when l or m is not an integer and zero when |m| > l
+func ylm(l, m, theta, phi float64) float64 {
+	L, ok1 := order(l)
+	M, ok2 := order(m)
+	if !ok1 || !ok2 || L < 0 {
+		return math.NaN()
+	}
+	if M > L || -M > L {
+		return 0
+	}
+	P := legendre(l, math.Abs(m), math.Cos(theta))
+	switch {
+	case M > 0:
+		return ylmFactor(L, M) * P * math.Cos(m*phi)
+	case M < 0:
+		return ylmFactor(L, M) * P * math.Sin(-m*phi)
+	default:
+		return ylmFactor(L, M) * P
+	}
+}
+
+// Returns the partial derivatives of legendre(l, m, x), where the degree and order are constants
+// (x^2-1) dP_l^m/dx = l x P_l^m - (l+m) P_(l-1)^m
+func legendreDeriv(args []*node) []*node {
+	l, m, x := args[0], args[1], args[2]
+	return []*node{num(0), num(0), op("/",
+		op("-", op("*", op("*", l, x), call("legendre", l, m, x)), op("*", op("+", l, m), call("legendre", op("-", l, num(1)), m, x))),
+		op("-", op("^", x, num(2)), num(1)))}
+}
+
+// Returns the partial derivatives of ylm(l, m, theta, phi), the degree l and order m must be numbers
+func ylmDeriv(args []*node) []*node {
+	l, m, theta, phi := constant(args[0]), constant(args[1]), args[2], args[3]
+	if l.kind != numNode || m.kind != numNode {
+		panic("The degree and order of ylm must be numbers to differentiate it")
+	}
+	L, ok1 := order(l.value)
+	M, ok2 := order(m.value)
+	if !ok1 || !ok2 || L < 0 || M > L || -M > L {
+		return []*node{num(0), num(0), num(0), num(0)}
+	}
+	absm := num(math.Abs(m.value))
+	var trig *node
+	switch {
+	case M > 0:
+		trig = call("cos", op("*", m, phi))
+	case M < 0:
+		trig = call("sin", op("*", absm, phi))
+	default:
+		trig = num(1)
+	}
+	// d/dtheta P_l^m(cos theta) = (l cos(theta) P_l^m(cos theta) - (l+m) P_(l-1)^m(cos theta)) / sin(theta)
+	x := call("cos", theta)
+	dP := op("/", op("-", op("*", op("*", l, x), call("legendre", l, absm, x)),
+		op("*", num(float64(L)+math.Abs(m.value)), call("legendre", num(float64(L-1)), absm, x))), call("sin", theta))
+	// d/dphi Y_lm = -m Y_l(-m)
+	return []*node{num(0), num(0),
+		op("*", op("*", num(ylmFactor(L, M)), dP), trig),
+		op("*", neg(m), call("ylm", l, neg(m), theta, phi))}
+}
+
+// Returns n with its negations and operations on numbers folded, so that a constant degree or order becomes a number
+func constant(n *node) *node {
+	switch n.kind {
+	case negNode:
+		return neg(constant(n.args[0]))
+	case opNode:
+		return op(n.op, constant(n.args[0]), constant(n.args[1]))
+	}
+	return n
+}
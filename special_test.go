@@ -0,0 +1,89 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLegendre(t *testing.T) {
+	x := 0.3
+	var tests = []struct {
+		l, m float64
+		exp  float64
+	}{
+		{0, 0, 1},
+		{2, 0, (3*x*x - 1) / 2},
+		{1, 1, -math.Sqrt(1 - x*x)},
+		{2, 1, -3 * x * math.Sqrt(1-x*x)},
+		{2, 2, 3 * (1 - x*x)},
+		{2, -1, x * math.Sqrt(1-x*x) / 2},
+		{3, 0, (5*x*x*x - 3*x) / 2},
+		{1, 2, 0},
+		{1.5, 0, math.NaN()},
+	}
+	for _, v := range tests {
+		if exp := legendre(v.l, v.m, x); !(math.Abs(exp-v.exp) < 1e-12 || math.IsNaN(exp) && math.IsNaN(v.exp)) {
+			t.Error("Test failed: {", v.l, v.m, x, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestYlm(t *testing.T) {
+	theta := 0.7
+	phi := 1.9
+	var tests = []struct {
+		l, m float64
+		exp  float64
+	}{
+		{0, 0, 1 / (2 * math.Sqrt(math.Pi))},
+		{1, 0, math.Sqrt(3/(4*math.Pi)) * math.Cos(theta)},
+		{1, 1, math.Sqrt(3/(4*math.Pi)) * math.Sin(theta) * math.Cos(phi)},
+		{1, -1, math.Sqrt(3/(4*math.Pi)) * math.Sin(theta) * math.Sin(phi)},
+		{2, 2, math.Sqrt(15/(16*math.Pi)) * math.Pow(math.Sin(theta), 2) * math.Cos(2*phi)},
+		{2, 3, 0},
+	}
+	for _, v := range tests {
+		if exp := ylm(v.l, v.m, theta, phi); math.Abs(exp-v.exp) > 1e-12 {
+			t.Error("Test failed: {", v.l, v.m, theta, phi, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestSpecialFunctions(t *testing.T) {
+	var tests = []struct {
+		expression string
+		exp        float64
+	}{
+		{"besselj(0, 0)", 1},
+		{"besselj(1, 2.5)", math.J1(2.5)},
+		{"bessely(2, 1.5)", math.Yn(2, 1.5)},
+		{"besselj(0.5, 1)", math.NaN()},
+		{"erf(0.5)+erfc(0.5)", 1},
+		{"gamma(5)", 24},
+	}
+	for _, v := range tests {
+		if exp := mustParse(v.expression).eval(nil, nil); !(math.Abs(exp-v.exp) < 1e-12 || math.IsNaN(exp) && math.IsNaN(v.exp)) {
+			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestDiffSpecialFunctions(t *testing.T) {
+	// Every derivative rule is compared to a central difference at a point inside the domain of every function
+	var tests = []string{
+		"besselj(2, x)", "besselj(0, x*y)", "bessely(1, x+1)", "legendre(3, 1, x)", "legendre(2, -1, x)", "legendre(4, 0, x*y)",
+		"ylm(2, 1, x, y)", "ylm(3, -2, x, y)", "ylm(2, 0, x, y)", "ylm(0, 0, x, y)", "erf(x*y)", "erfc(x)",
+	}
+	coords := []string{"x", "y"}
+	c := []float64{0.3, 0.7}
+	h := 0.0001
+	for _, v := range tests {
+		n := mustParse(v)
+		for i, name := range coords {
+			exp := (n.eval(shift(c, i, h), coords) - n.eval(shift(c, i, -h), coords)) / (2 * h)
+			if d := n.diff(name).eval(c, coords); math.Abs(d-exp) > 1e-6 {
+				t.Error("Test failed: {", v, name, " } inputted, expected {", exp, "} and got {", d, "} from {", n.diff(name), "}")
+			}
+		}
+	}
+}
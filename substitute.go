@@ -0,0 +1,167 @@
+package vcalc
+
+// Returns the tree n where every coordinate or parameter in values is replaced by its tree
+// All names are replaced at once, so a replacement is not itself substituted again
+func (n *node) substitute(values map[string]*node) *node {
+	switch n.kind {
+	case numNode:
+		return n
+	case varNode:
+		if v, ok := values[n.name]; ok {
+			return v
+		}
+		return n
+	default:
+		args := make([]*node, len(n.args))
+		for i, arg := range n.args {
+			args[i] = arg.substitute(values)
+		}
+		return &node{kind: n.kind, name: n.name, op: n.op, args: args, fn: n.fn, implicit: n.implicit}
+	}
+}
+
+// An Expression is substituted for a name in a field by Substitute, either text like the expression of a field
+// written as Expr or a scalar field, whose expression is substituted and whose parameters, bound values and custom
+// functions are added to the field
+type Expression interface {
+	// Returns the settings o combined with the settings of the expression
+	mergeInto(o fieldOptions) fieldOptions
+	// Returns the tree of the expression, parsed with the settings o
+	substitution(o fieldOptions) *node
+}
+
+// An Expr is an expression written as text, like "sqrt(x^2+y^2)", which is substituted into a field
+type Expr string
+
+func (e Expr) mergeInto(o fieldOptions) fieldOptions {
+	return o
+}
+
+func (e Expr) substitution(o fieldOptions) *node {
+	return o.mustParse(string(e))
+}
+
+func (s scalarField) mergeInto(o fieldOptions) fieldOptions {
+	return o.merge(s.opts)
+}
+
+func (s scalarField) substitution(o fieldOptions) *node {
+	return s.tree
+}
+
+func (s scalarField2D) mergeInto(o fieldOptions) fieldOptions {
+	return o.merge(s.opts)
+}
+
+func (s scalarField2D) substitution(o fieldOptions) *node {
+	return s.tree
+}
+
+func (s scalarFieldN) mergeInto(o fieldOptions) fieldOptions {
+	return o.merge(s.opts)
+}
+
+func (s scalarFieldN) substitution(o fieldOptions) *node {
+	return s.tree
+}
+
+// Returns the expressions where every name in exprs is replaced by its expression and the settings o combined with
+// those of the expressions, with which the expressions and the result are parsed
+// Panics if a name in exprs is not one of names
+func (o fieldOptions) substitute(names []string, exprs map[string]Expression, expressions ...string) (fieldOptions, []string) {
+	for name, e := range exprs {
+		declared := false
+		for _, v := range names {
+			declared = declared || v == name
+		}
+		if !declared {
+			panic("Can not substitute " + name + ", it is neither a coordinate, a parameter nor the time of the field")
+		}
+		o = e.mergeInto(o)
+	}
+	values := make(map[string]*node, len(exprs))
+	for name, e := range exprs {
+		values[name] = e.substitution(o)
+	}
+	res := make([]string, len(expressions))
+	for i, e := range expressions {
+		res[i] = o.mustParse(e).substitute(values).String()
+	}
+	return o, res
+}
+
+// Returns the names which can be substituted in a field in coordsys with the settings o,
+// the coordinates followed by the parameters and the time
+func (o fieldOptions) substitutable(coordsys string) []string {
+	return append(append(append([]string(nil), coordNames(coordsys)...), o.params...), timeName)
+}
+
+// Checks that the coordinate system to substitute a field to has as many coordinates as the field, panics if not
+func checkSubstituteCoords(from, to string) {
+	if coordNames(to) == nil || len(coordNames(to)) != len(coordNames(from)) {
+		panic("Can not substitute a field in " + from + " to " + to + ", the coordinate systems must have the same number of coordinates")
+	}
+}
+
+// Returns a new scalar field where every coordinate or parameter in exprs is replaced by its expression
+// The expressions can use the coordinates, parameters and functions of the field and those of the fields in exprs,
+// panics if they do not
+func (s scalarField) Substitute(exprs map[string]Expression) scalarField {
+	return s.SubstituteIn(s.coordsys, exprs)
+}
+
+// Returns a new scalar field in coordsys where every coordinate or parameter in exprs is replaced by its expression,
+// like replacing r and phi with sqrt(x^2+y^2) and atan2(y, x) to write a field in cylinder coordinates in "car"
+// The expressions use the coordinates of coordsys, panics if a coordinate of the field is left
+func (s scalarField) SubstituteIn(coordsys string, exprs map[string]Expression) scalarField {
+	checkSubstituteCoords(s.coordsys, coordsys)
+	opts, e := s.opts.substitute(s.opts.substitutable(s.coordsys), exprs, s.expression)
+	s.coordsys, s.opts = coordsys, opts
+	checkCoords(e[0], s.coordsys, s.opts)
+	return s.withExpression(e[0])
+}
+
+// Returns a new vector field where every coordinate or parameter in exprs is replaced by its expression in each component
+// The components stay in the unit vectors of the coordinate system of the field
+func (v vectorField) Substitute(exprs map[string]Expression) vectorField {
+	opts, e := v.opts.substitute(v.opts.substitutable(v.coordsys), exprs, v.expressionCoord1, v.expressionCoord2, v.expressionCoord3)
+	v.opts = opts
+	for _, e := range e {
+		checkCoords(e, v.coordsys, v.opts)
+	}
+	return v.withExpressions(e[0], e[1], e[2])
+}
+
+// Returns a new two-dimensional scalar field where every coordinate or parameter in exprs is replaced by its expression
+func (s scalarField2D) Substitute(exprs map[string]Expression) scalarField2D {
+	return s.SubstituteIn(s.coordsys, exprs)
+}
+
+// Returns a new two-dimensional scalar field in coordsys where every coordinate or parameter in exprs is replaced
+// by its expression, like SubstituteIn of scalarField
+func (s scalarField2D) SubstituteIn(coordsys string, exprs map[string]Expression) scalarField2D {
+	checkSubstituteCoords(s.coordsys, coordsys)
+	opts, e := s.opts.substitute(s.opts.substitutable(s.coordsys), exprs, s.expression)
+	s.coordsys, s.opts = coordsys, opts
+	checkCoords(e[0], s.coordsys, s.opts)
+	return s.withExpression(e[0])
+}
+
+// Returns a new two-dimensional vector field where every coordinate or parameter in exprs is replaced by its
+// expression in each component
+func (v vectorField2D) Substitute(exprs map[string]Expression) vectorField2D {
+	opts, e := v.opts.substitute(v.opts.substitutable(v.coordsys), exprs, v.expressionCoord1, v.expressionCoord2)
+	v.opts = opts
+	for _, e := range e {
+		checkCoords(e, v.coordsys, v.opts)
+	}
+	return v.withExpressions(e[0], e[1])
+}
+
+// Returns a new N-dimensional scalar field where every variable in exprs is replaced by its expression
+func (s scalarFieldN) Substitute(exprs map[string]Expression) scalarFieldN {
+	opts, e := s.opts.substitute(s.coords, exprs, s.expression)
+	s.opts = opts
+	checkVars(e[0], s.coords, s.opts)
+	return s.withExpression(e[0])
+}
@@ -0,0 +1,97 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{NewScalarField("q/r^2", "sph", WithParams("q")).Substitute(map[string]Expression{"q": Expr("2cos(theta)")}).String(), "2*cos(theta)/r^2"},
+		{NewScalarField("x^2-y", "car").Substitute(map[string]Expression{"x": Expr("y"), "y": Expr("x")}).String(), "y^2-x"},
+		{NewScalarField("x^2*y", "car").Substitute(map[string]Expression{"x": Expr("z+1")}).String(), "(z+1)^2*y"},
+		{NewScalarField("r^2*cos(phi)", "cyl").SubstituteIn("car", map[string]Expression{"r": Expr("sqrt(x^2+y^2)"), "phi": Expr("atan2(y, x)")}).String(), "sqrt(x^2+y^2)^2*cos(atan2(y, x))"},
+		{NewVectorField("r", "r*z", "0", "cyl").Substitute(map[string]Expression{"z": Expr("-z")}).String(), "(r, r*-z, 0)"},
+		{NewScalarField2D("x*y", "car2").SubstituteIn("polar", map[string]Expression{"x": Expr("r*cos(phi)"), "y": Expr("r*sin(phi)")}).String(), "r*cos(phi)*(r*sin(phi))"},
+		{NewVectorField2D("x", "y^2", "car2").Substitute(map[string]Expression{"y": Expr("x")}).String(), "(x, x^2)"},
+		{NewScalarFieldN("x1*x2", []string{"x1", "x2", "x3"}).Substitute(map[string]Expression{"x2": Expr("x3^2")}).String(), "x1*x3^2"},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestSubstituteIn(t *testing.T) {
+	// A field moved to cartesian coordinates has the same value at the same point
+	s := NewScalarField("r^2*cos(phi)+z", "cyl")
+	car := s.SubstituteIn("car", map[string]Expression{"r": Expr("sqrt(x^2+y^2)"), "phi": Expr("atan2(y, x)")})
+	for _, c := range [][]float64{{1, 0.5, 2}, {2, -2, 0}, {0.3, 3, -1}} {
+		p := toCartesian(c, "cyl")
+		if exp, got := s.fn(c[0], c[1], c[2]), car.fn(p[0], p[1], p[2]); math.Abs(exp-got) > 1e-12 {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+	// The bound parameters are kept
+	q := NewScalarField("q*x", "car", WithParams("q")).Bind("q", 3).Substitute(map[string]Expression{"x": Expr("x+y")})
+	if exp := q.fn(1, 1, 0); exp != 6 {
+		t.Error("Test failed: {", q, " } inputted, expected {", 6, "} and got {", exp, "}")
+	}
+}
+
+func TestSubstituteField(t *testing.T) {
+	// A substituted field brings its parameters, bound values and custom functions
+	r := NewFuncRegistry()
+	r.RegisterFunc("twice", 1, func(args ...float64) float64 {
+		return 2 * args[0]
+	})
+	k := NewScalarField("k*twice(y)", "car", WithParams("k"), WithFuncs(r)).Bind("k", 3)
+	s := NewScalarField("x^2+z", "car").Substitute(map[string]Expression{"x": k, "z": Expr("twice(z)")})
+	if exp := s.String(); exp != "(k*twice(y))^2+twice(z)" {
+		t.Error("Test failed: {", k, " } inputted, expected {", "(k*twice(y))^2+twice(z)", "} and got {", exp, "}")
+	}
+	if exp := s.fn(0, 1, 2); exp != 40 {
+		t.Error("Test failed: {", s, " } inputted, expected {", 40, "} and got {", exp, "}")
+	}
+	// A field in another coordinate system is substituted by its expression, which must fit the coordinates
+	n := NewScalarFieldN("x1+x2", []string{"x1", "x2"}).Substitute(map[string]Expression{"x2": NewScalarFieldN("x1^2", []string{"x1"})})
+	if exp := n.String(); exp != "x1+x1^2" {
+		t.Error("Test failed: {", n, " } inputted, expected {", "x1+x1^2", "} and got {", exp, "}")
+	}
+	p := NewScalarField2D("r*phi", "polar").Substitute(map[string]Expression{"phi": NewScalarField("r", "sph")})
+	if exp := p.String(); exp != "r*r" {
+		t.Error("Test failed: {", p, " } inputted, expected {", "r*r", "} and got {", exp, "}")
+	}
+}
+
+func TestSubstitutePanics(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"unknown name", func() { NewScalarField("x", "car").Substitute(map[string]Expression{"k": Expr("2")}) }},
+		{"coordinate of other system", func() { NewScalarField("x", "car").Substitute(map[string]Expression{"x": Expr("r")}) }},
+		{"coordinate left", func() { NewScalarField("r*phi", "cyl").SubstituteIn("car", map[string]Expression{"r": Expr("x")}) }},
+		{"other dimension", func() { NewScalarField("x", "car").SubstituteIn("car2", map[string]Expression{"z": Expr("0")}) }},
+		{"syntax error", func() { NewScalarField("x", "car").Substitute(map[string]Expression{"x": Expr("y+")}) }},
+		{"undeclared parameter", func() { NewVectorField("x", "y", "z", "car").Substitute(map[string]Expression{"x": Expr("k")}) }},
+		{"field in other coordinates", func() { NewScalarField("x", "car").Substitute(map[string]Expression{"x": NewScalarField("r", "cyl")}) }},
+		{"field at other time", func() {
+			NewScalarField("x", "car").Substitute(map[string]Expression{"x": NewScalarField("t*y", "car").At(1)})
+		}},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic and got none")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
@@ -0,0 +1,216 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// A patch is a piece of a surface given by the point at each value of its parameters u and v, in a coordinate system
+// with three coordinates, where u goes from u[0] to u[1] and v from v[0] to v[1]
+// The point is given by the parsed expression of u and v for each coordinate or by a function of u and v
+type patch struct {
+	expressions []*node
+	// The derivatives of expressions with respect to u and v, nil if an expression calls a function without derivative
+	du, dv   []*node
+	f        func(u, v float64) []float64
+	coordsys string
+	u, v     [2]float64
+}
+
+// A surface is made of one or more patches, whose normal is the cross product of the derivatives of the point with
+// respect to u and v. The built-in closed surfaces have their normal pointing outwards
+type surface struct {
+	patches []patch
+	opts    fieldOptions
+}
+
+// Returns a new surface whose coordinates in coordsys are the expressions e1, e2, e3 of the parameters u and v,
+// where u goes from u0 to u1 and v from v0 to v1
+// The options WithParams, WithFuncs and WithStrictSyntax apply, the parameters are bound with Bind or BindParams
+func NewSurface(e1, e2, e3, coordsys string, u0, u1, v0, v1 float64, opts ...FieldOption) surface {
+	checkSurfaceCoords(coordsys)
+	o := newFieldOptions(opts)
+	vars := append([]string{"u", "v"}, o.params...)
+	trees := make([]*node, 3)
+	for i, e := range []string{e1, e2, e3} {
+		checkVars(e, vars, o)
+		trees[i] = o.mustParse(e)
+	}
+	p := patch{expressions: trees, du: derivatives(trees, "u"), dv: derivatives(trees, "v"), coordsys: coordsys}
+	p.u, p.v = [2]float64{u0, u1}, [2]float64{v0, v1}
+	return surface{[]patch{p}, o}
+}
+
+// Returns a new surface whose coordinates in coordsys at the parameters u and v are f(u, v), where u goes from u0
+// to u1 and v from v0 to v1
+func NewSurfaceFunc(f func(u, v float64) []float64, coordsys string, u0, u1, v0, v1 float64) surface {
+	checkSurfaceCoords(coordsys)
+	return surface{patches: []patch{{f: f, coordsys: coordsys, u: [2]float64{u0, u1}, v: [2]float64{v0, v1}}}}
+}
+
+// Returns the sphere with the cartesian center c and the radius R
+func NewSphere(c []float64, R float64) surface {
+	checkCenter(c)
+	return NewSurfaceFunc(func(theta, phi float64) []float64 {
+		return []float64{
+			c[0] + R*math.Sin(theta)*math.Cos(phi),
+			c[1] + R*math.Sin(theta)*math.Sin(phi),
+			c[2] + R*math.Cos(theta)}
+	}, "car", 0, math.Pi, 0, 2*math.Pi)
+}
+
+// Returns the closed cylinder with the radius R and the height h along z, whose bottom has the cartesian center c
+// The surface is the side of the cylinder and its top and bottom
+func NewCylinder(c []float64, R, h float64) surface {
+	checkCenter(c)
+	side := func(phi, z float64) []float64 {
+		return []float64{c[0] + R*math.Cos(phi), c[1] + R*math.Sin(phi), c[2] + z}
+	}
+	top := func(rho, phi float64) []float64 {
+		return []float64{c[0] + rho*math.Cos(phi), c[1] + rho*math.Sin(phi), c[2] + h}
+	}
+	bottom := func(phi, rho float64) []float64 {
+		return []float64{c[0] + rho*math.Cos(phi), c[1] + rho*math.Sin(phi), c[2]}
+	}
+	return surface{patches: []patch{
+		{f: side, coordsys: "car", u: [2]float64{0, 2 * math.Pi}, v: [2]float64{0, h}},
+		{f: top, coordsys: "car", u: [2]float64{0, R}, v: [2]float64{0, 2 * math.Pi}},
+		{f: bottom, coordsys: "car", u: [2]float64{0, 2 * math.Pi}, v: [2]float64{0, R}}}}
+}
+
+// Returns the parallelogram with the cartesian corner origin and the sides a and b, whose normal is a×b
+func NewPlanePatch(origin, a, b []float64) surface {
+	checkCenter(origin)
+	checkCenter(a)
+	checkCenter(b)
+	return NewSurfaceFunc(func(u, v float64) []float64 {
+		res := make([]float64, 3)
+		for i := range res {
+			res[i] = origin[i] + u*a[i] + v*b[i]
+		}
+		return res
+	}, "car", 0, 1, 0, 1)
+}
+
+// Returns the surface of the box with the sides along the cartesian axes from the corner lower to the corner upper
+func NewBox(lower, upper []float64) surface {
+	checkCenter(lower)
+	checkCenter(upper)
+	s := surface{}
+	for k := 0; k < 3; k++ {
+		// The unit vectors i, j, k are in cyclic order, so that the side along i crossed with the side along j is
+		// along k, on the face at upper, and against k on the face at lower
+		i, j := (k+1)%3, (k+2)%3
+		a, b, top := make([]float64, 3), make([]float64, 3), make([]float64, 3)
+		a[i], b[j] = upper[i]-lower[i], upper[j]-lower[j]
+		copy(top, lower)
+		top[k] = upper[k]
+		s.patches = append(s.patches, NewPlanePatch(top, a, b).patches[0], NewPlanePatch(lower, b, a).patches[0])
+	}
+	return s
+}
+
+// Checks that coordsys is a coordinate system with three coordinates, panics if not
+func checkSurfaceCoords(coordsys string) {
+	if len(coordNames(coordsys)) != 3 {
+		panic("A surface needs the coordinate system car, cyl or sph")
+	}
+}
+
+// Checks that c is a point with three coordinates, panics if not
+func checkCenter(c []float64) {
+	if len(c) != 3 {
+		panic("Too many or too few points coordinates given")
+	}
+}
+
+// Returns a copy of surface where the parameter name has the value value
+func (s surface) Bind(name string, value float64) surface {
+	return s.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of surface where each parameter in values has its value
+func (s surface) BindParams(values map[string]float64) surface {
+	s.opts = s.opts.bind(values)
+	return s
+}
+
+// Returns the coordinates of the point of patch p at the parameters u and v
+func (s surface) point(p patch, u, v float64) []float64 {
+	if p.f != nil {
+		return p.f(u, v)
+	}
+	c, names := s.opts.withParams([]float64{u, v}, []string{"u", "v"})
+	res := make([]float64, len(p.expressions))
+	for i, e := range p.expressions {
+		res[i] = s.opts.fnN(c, e, names)
+	}
+	return res
+}
+
+// Returns the coordinates in coordsys of the point of patch p at the parameters u and v, like in of curve
+func (s surface) in(p patch, u, v float64, coordsys string) []float64 {
+	if p.coordsys == coordsys {
+		return s.point(p, u, v)
+	}
+	return fromCartesian(toCartesian(s.point(p, u, v), p.coordsys), coordsys)
+}
+
+// Returns the normal of patch p at the parameters u and v, the cross product of the derivatives of the cartesian
+// coordinates of the point with respect to u and v, whose length is the area of the surface per area of u and v
+// The derivatives of a patch given by expressions are found symbolically and of a patch given by a function with
+// central differences
+func (s surface) normal(p patch, u, v float64) []float64 {
+	if p.du != nil && p.dv != nil {
+		c, names := s.opts.withParams([]float64{u, v}, []string{"u", "v"})
+		du, dv := make([]float64, 3), make([]float64, 3)
+		for i := range du {
+			du[i], dv[i] = s.opts.fnN(c, p.du[i], names), s.opts.fnN(c, p.dv[i], names)
+		}
+		q := s.point(p, u, v)
+		return cross(velocity(q, du, p.coordsys), velocity(q, dv, p.coordsys))
+	}
+	d := func(a, b []float64, h float64) []float64 {
+		res := make([]float64, 3)
+		for i := range res {
+			res[i] = (a[i] - b[i]) / (2 * h)
+		}
+		return res
+	}
+	cartesian := func(u, v float64) []float64 {
+		return toCartesian(s.point(p, u, v), p.coordsys)
+	}
+	hu, hv := 1e-5*math.Max(1, math.Abs(u)), 1e-5*math.Max(1, math.Abs(v))
+	a := d(cartesian(u+hu, v), cartesian(u-hu, v), hu)
+	b := d(cartesian(u, v+hv), cartesian(u, v-hv), hv)
+	return cross(a, b)
+}
+
+// Returns the cross product a×b of the cartesian vectors a and b
+func cross(a, b []float64) []float64 {
+	return []float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// Returns the integral of F·dS over surface, where F takes a point in coordsys and returns the components in its
+// unit vectors, and an estimate of the error of the integral
+func flux(F func([]float64) []float64, coordsys string, s surface, opts []IntegralOption) (float64, float64) {
+	o := newIntegralOptions(opts)
+	var res, err float64
+	for _, p := range s.patches {
+		val, e := integrate2D(func(u, v float64) float64 {
+			q := s.in(p, u, v, coordsys)
+			f := vecToCartesian(F(q), q, coordsys)
+			n := s.normal(p, u, v)
+			return f[0]*n[0] + f[1]*n[1] + f[2]*n[2]
+		}, p.u, p.v, o)
+		res += val
+		err += e
+	}
+	return res, err
+}
+
+// Calculates the flux of vectorField through surface, the integral of F·dS along the normal of the surface, with
+// adaptive cubature over the parameters of each patch
+// Returns the integral and an estimate of its error. The surface can be in another coordinate system than the field
+func (v vectorField) Flux(s surface, opts ...IntegralOption) (float64, float64) {
+	return flux(v.eval, v.coordsys, s, opts)
+}
@@ -0,0 +1,105 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlux(t *testing.T) {
+	charge := NewVectorField("1/r^2", "0", "0", "sph")
+	position := NewVectorField("x", "y", "z", "car")
+	var tests = []struct {
+		v   vectorField
+		s   surface
+		exp float64
+	}{
+		// Gauss's law gives 4*pi for a unit charge inside the surface and 0 for a charge outside
+		{charge, NewSphere([]float64{0, 0, 0}, 2), 4 * math.Pi},
+		{charge, NewSphere([]float64{0.5, -0.3, 0.2}, 1), 4 * math.Pi},
+		{charge, NewBox([]float64{-1, -2, -0.5}, []float64{1, 1, 1}), 4 * math.Pi},
+		{charge, NewCylinder([]float64{0, 0, -1}, 1, 2), 4 * math.Pi},
+		{charge, NewBox([]float64{1, 1, 1}, []float64{2, 3, 2}), 0},
+		// The flux of the position is three times the volume inside the surface
+		{position, NewSphere([]float64{1, 0, 0}, 1), 4 * math.Pi},
+		{position, NewBox([]float64{0, 0, 0}, []float64{1, 2, 3}), 18},
+		{position, NewCylinder([]float64{0, 0, 0}, 2, 1), 12 * math.Pi},
+		{position, NewSurface("2", "u", "v", "sph", 0, math.Pi, 0, 2*math.Pi), 32 * math.Pi},
+		{NewVectorField("0", "0", "1", "car"), NewPlanePatch([]float64{0, 0, 1}, []float64{2, 0, 0}, []float64{0, 3, 0}), 6},
+		{NewVectorField("0", "0", "1", "car"), NewPlanePatch([]float64{0, 0, 1}, []float64{0, 3, 0}, []float64{2, 0, 0}), -6},
+		{NewVectorField("r", "0", "0", "cyl"), NewSurface("u", "v", "1", "cyl", 0, 1, 0, 2*math.Pi), 0},
+	}
+	for _, v := range tests {
+		if got, err := v.v.Flux(v.s); math.Abs(got-v.exp) > 1e-6 || err > 1e-6 {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, "} and got {", got, err, "}")
+		}
+	}
+	// A sphere with a bound radius and a surface given by a function
+	R := NewSurface("R*sin(u)*cos(v)", "R*sin(u)*sin(v)", "R*cos(u)", "car", 0, math.Pi, 0, 2*math.Pi, WithParams("R")).Bind("R", 3)
+	disc := NewSurfaceFunc(func(u, v float64) []float64 { return []float64{u, v, 0} }, "cyl", 0, 2, 0, 2*math.Pi)
+	var surfaces = []struct {
+		v   vectorField
+		s   surface
+		exp float64
+	}{
+		{position, R, 108 * math.Pi},
+		{NewVectorField("0", "0", "x^2+y^2", "car"), disc, 8 * math.Pi},
+	}
+	for _, v := range surfaces {
+		if got, _ := v.v.Flux(v.s, WithTolerance(1e-10)); math.Abs(got-v.exp) > 1e-6 {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestNormal(t *testing.T) {
+	r := NewFuncRegistry()
+	r.RegisterFunc("square", 1, func(args ...float64) float64 {
+		return args[0] * args[0]
+	})
+	u, v := 0.7, 2.1
+	radial := []float64{math.Sin(u) * math.Cos(v), math.Sin(u) * math.Sin(v), math.Cos(u)}
+	var tests = []struct {
+		s   surface
+		exp []float64
+		tol float64
+	}{
+		{NewSurface("2", "u", "v", "sph", 0, math.Pi, 0, 2*math.Pi), []float64{4 * math.Sin(u) * radial[0], 4 * math.Sin(u) * radial[1], 4 * math.Sin(u) * radial[2]}, 1e-12},
+		{NewSurface("R*sin(u)*cos(v)", "R*sin(u)*sin(v)", "R*cos(u)", "car", 0, math.Pi, 0, 2*math.Pi, WithParams("R")).Bind("R", 2),
+			[]float64{4 * math.Sin(u) * radial[0], 4 * math.Sin(u) * radial[1], 4 * math.Sin(u) * radial[2]}, 1e-12},
+		{NewSurface("3", "u", "v", "cyl", 0, 2*math.Pi, 0, 1), []float64{3 * math.Cos(u), 3 * math.Sin(u), 0}, 1e-12},
+		// A function without derivative and a surface given by a function are differentiated numerically
+		{NewSurface("u", "v", "square(u)", "car", 0, 1, 0, 1, WithFuncs(r)), []float64{-2 * u, 0, 1}, 1e-6},
+		{NewPlanePatch([]float64{0, 0, 0}, []float64{2, 0, 0}, []float64{0, 3, 0}), []float64{0, 0, 6}, 1e-6},
+	}
+	for _, w := range tests {
+		got := w.s.normal(w.s.patches[0], u, v)
+		for i := range got {
+			if math.Abs(got[i]-w.exp[i]) > w.tol {
+				t.Error("Test failed: {", u, v, " } inputted, expected {", w.exp, "} and got {", got, "}")
+				break
+			}
+		}
+	}
+}
+
+func TestSurfaceErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"undeclared name", func() { NewSurface("u", "w", "0", "car", 0, 1, 0, 1) }},
+		{"two-dimensional system", func() { NewSurface("u", "v", "0", "polar", 0, 1, 0, 1) }},
+		{"center", func() { NewSphere([]float64{0, 0}, 1) }},
+		{"corner", func() { NewBox([]float64{0, 0, 0}, []float64{1, 1}) }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
@@ -0,0 +1,243 @@
+package vcalc
+
+import (
+	"math"
+	"strings"
+)
+
+// A tensor field is a rank-2 tensor with a mathematical expression for each of its nine components and a coordinate
+// system defined as "car" for cartesian, "cyl" for cylinder, "sph" for spherical
+// The component in row i and column j is along the unit vectors i and j of the coordinate system
+type tensorField struct {
+	expressions [3][3]string
+	trees       [3][3]*node
+	coordsys    string
+	opts        fieldOptions
+}
+
+// Returns a new tensor field with the component e[i][j] in row i and column j, the options opts change the default
+// settings of the field
+func NewTensorField(e [3][3]string, coordsys string, opts ...FieldOption) tensorField {
+	t := tensorField{}
+	t.expressions = e
+	t.coordsys = coordsys
+	t.opts = newFieldOptions(opts)
+	for i, row := range e {
+		for j, e := range row {
+			checkCoords(e, coordsys, t.opts)
+			t.trees[i][j] = t.opts.mustParse(e)
+		}
+	}
+	return t
+}
+
+// Returns a copy of tensorField where the parameter name has the value value
+func (t tensorField) Bind(name string, value float64) tensorField {
+	return t.BindParams(map[string]float64{name: value})
+}
+
+// Returns a copy of tensorField where each parameter in values has its value
+func (t tensorField) BindParams(values map[string]float64) tensorField {
+	t.opts = t.opts.bind(values)
+	return t
+}
+
+// Returns a copy of tensorField at the time t, where it is evaluated and its operators are calculated
+func (t tensorField) At(time float64) tensorField {
+	t.opts.time = time
+	return t
+}
+
+// Returns the rows of tensorField as canonical text like ((x, 0, 0), (0, y, 0), (0, 0, z))
+func (t tensorField) String() string {
+	rows := make([]string, 3)
+	for i, row := range t.expressions {
+		rows[i] = t.opts.vectorString(row[:]...)
+	}
+	return "(" + strings.Join(rows, ", ") + ")"
+}
+
+// Returns the component in row i and column j of tensorField as a function of a point
+func (t tensorField) component(i, j int) func([]float64) float64 {
+	return func(c []float64) float64 {
+		c, coords := t.opts.withTime(c, coordNames(t.coordsys))
+		return t.opts.fnN(c, t.trees[i][j], coords)
+	}
+}
+
+// Returns the calculation of each component of tensorField at point c with its parameters bound
+func (t tensorField) eval(c []float64) [][]float64 {
+	res := make([][]float64, 3)
+	for i := range res {
+		res[i] = make([]float64, 3)
+		for j := range res[i] {
+			res[i][j] = t.component(i, j)(c)
+		}
+	}
+	return res
+}
+
+// Returns a new tensor field with the expression f(i, j) as the component in row i and column j, in the
+// coordinate system of tensorField and with the settings opts
+func (t tensorField) with(opts fieldOptions, f func(i, j int) *node) tensorField {
+	var e [3][3]string
+	for i := range e {
+		for j := range e[i] {
+			e[i][j] = f(i, j).String()
+		}
+	}
+	return NewTensorField(e, t.coordsys, withOptions(opts))
+}
+
+// Returns the trees of the components of tensorField
+func (t tensorField) nodes() [3][3]*node {
+	return t.trees
+}
+
+// Returns the trace of tensorField, the sum of its diagonal components, as a new scalar field
+func (t tensorField) Trace() scalarField {
+	T := t.nodes()
+	return NewScalarField(op("+", op("+", T[0][0], T[1][1]), T[2][2]).String(), t.coordsys, withOptions(t.opts))
+}
+
+// Returns the transpose of tensorField as a new tensor field, where the rows and columns are swapped
+func (t tensorField) Transpose() tensorField {
+	T := t.nodes()
+	return t.with(t.opts, func(i, j int) *node {
+		return T[j][i]
+	})
+}
+
+// Returns the symmetric part (T+T^T)/2 of tensorField as a new tensor field
+func (t tensorField) Symmetric() tensorField {
+	T := t.nodes()
+	return t.with(t.opts, func(i, j int) *node {
+		if i == j {
+			return T[i][j]
+		}
+		return op("/", op("+", T[i][j], T[j][i]), num(2))
+	})
+}
+
+// Returns the antisymmetric part (T-T^T)/2 of tensorField as a new tensor field
+func (t tensorField) Antisymmetric() tensorField {
+	T := t.nodes()
+	return t.with(t.opts, func(i, j int) *node {
+		if i == j {
+			return num(0)
+		}
+		return op("/", op("-", T[i][j], T[j][i]), num(2))
+	})
+}
+
+// Returns the contraction T·v of tensorField with the vector field v, the vector with the components T[i][j]*v[j],
+// as a new vector field in the coordinate system of tensorField
+// If v is in another coordinate system its components are written in the coordinates and unit vectors of tensorField,
+// like Add of vectorField. The contraction v·T is found with Transpose
+func (t tensorField) Dot(v vectorField) vectorField {
+	opts, _, b := operands(t.opts, t.coordsys, nil, v.opts, v.coordsys, true, v.expressions())
+	T := t.nodes()
+	e := make([]string, 3)
+	for i := range e {
+		e[i] = dotNodes(T[i][:], b)
+	}
+	return NewVectorField(e[0], e[1], e[2], t.coordsys, withOptions(opts))
+}
+
+// Returns the double contraction u·T·v of tensorField with the vector fields u and v, the sum of u[i]*T[i][j]*v[j],
+// as a new scalar field in the coordinate system of tensorField, like Dot
+func (t tensorField) Contract(u, v vectorField) scalarField {
+	opts, _, a := operands(t.opts, t.coordsys, nil, u.opts, u.coordsys, true, u.expressions())
+	opts, _, b := operands(opts, t.coordsys, nil, v.opts, v.coordsys, true, v.expressions())
+	T := t.nodes()
+	res := num(0)
+	for i := range a {
+		for j := range b {
+			res = op("+", res, op("*", op("*", a[i], T[i][j]), b[j]))
+		}
+	}
+	return NewScalarField(res.String(), t.coordsys, withOptions(opts))
+}
+
+// Calculates the divergence of tensorField, the vector with the components sum over i of d_i T[i][j] including the
+// terms from the change of the unit vectors in cylinder and spherical coordinates
+// Returns a slice of float64 containg the calculated divergence at point c
+func (t tensorField) Div(c []float64) []float64 {
+	div, err := t.TryDiv(c)
+	if err != nil {
+		panic(singularMsg(t.coordsys))
+	}
+	return div
+}
+
+// Calculates the divergence of tensorField like Div
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (t tensorField) TryDiv(c []float64) ([]float64, error) {
+	if len(c) != 3 {
+		panic("Too many or too few points coordinates given")
+	}
+	return atSingularity(c, t.coordsys, t.opts.singularity, t.div, func(c []float64) []float64 {
+		return divTensorCartesian(t.eval, c, t.coordsys)
+	})
+}
+
+// Returns the divergence of tensorField at point c, which is not a singular point
+func (t tensorField) div(c []float64) []float64 {
+	T := func(i, j int) float64 {
+		return t.component(i, j)(c)
+	}
+	// d(i, j, k) is the partial derivative of the component T[i][j] with respect to the coordinate k
+	d := func(i, j, k int) float64 {
+		return partial(t.component(i, j), c, k)
+	}
+	switch t.coordsys {
+	case "car":
+		return []float64{
+			d(0, 0, 0) + d(1, 0, 1) + d(2, 0, 2),
+			d(0, 1, 0) + d(1, 1, 1) + d(2, 1, 2),
+			d(0, 2, 0) + d(1, 2, 1) + d(2, 2, 2)}
+
+	case "cyl":
+		r := c[0]
+		return []float64{
+			d(0, 0, 0) + d(1, 0, 1)/r + d(2, 0, 2) + (T(0, 0)-T(1, 1))/r,
+			d(0, 1, 0) + d(1, 1, 1)/r + d(2, 1, 2) + (T(0, 1)+T(1, 0))/r,
+			d(0, 2, 0) + d(1, 2, 1)/r + d(2, 2, 2) + T(0, 2)/r}
+	case "sph":
+		r := c[0]
+		sin := math.Sin(c[1])
+		cot := math.Cos(c[1]) / sin
+		return []float64{
+			d(0, 0, 0) + d(1, 0, 1)/r + d(2, 0, 2)/(r*sin) + (2*T(0, 0)+cot*T(1, 0)-T(1, 1)-T(2, 2))/r,
+			d(0, 1, 0) + d(1, 1, 1)/r + d(2, 1, 2)/(r*sin) + (2*T(0, 1)+cot*T(1, 1)+T(1, 0)-cot*T(2, 2))/r,
+			d(0, 2, 0) + d(1, 2, 1)/r + d(2, 2, 2)/(r*sin) + (2*T(0, 2)+cot*T(1, 2)+T(2, 0)+cot*T(2, 1))/r}
+	default:
+		panic("Error finding Div, coordinates system is wrong")
+	}
+}
+
+// Calculates the divergence of the tensor field T at point c through cartesian coordinates
+func divTensorCartesian(T func([]float64) [][]float64, c []float64, coordsys string) []float64 {
+	// The cartesian component a, b of T is the sum of T[i][j] times the components a and b of the unit vectors i and j
+	component := func(a, b int) func([]float64) float64 {
+		return func(p []float64) float64 {
+			q := fromCartesian(p, coordsys)
+			e, t := basis(q, coordsys), T(q)
+			var res float64
+			for i := range t {
+				for j := range t[i] {
+					res += t[i][j] * e[i][a] * e[j][b]
+				}
+			}
+			return res
+		}
+	}
+	p := toCartesian(c, coordsys)
+	div := make([]float64, len(p))
+	for b := range div {
+		for a := range p {
+			div[b] += partial(component(a, b), p, a)
+		}
+	}
+	return vecFromCartesian(div, c, coordsys)
+}
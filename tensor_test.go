@@ -0,0 +1,87 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTensorDiv(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		t     tensorField
+		exp   []float64
+	}{
+		{[]float64{1, 2, 3}, NewTensorField([3][3]string{{"x^2", "0", "0"}, {"0", "y", "0"}, {"0", "0", "z*x"}}, "car"), []float64{2, 1, 1}},
+		{[]float64{1, 2, 3}, NewTensorField([3][3]string{{"0", "x*y", "0"}, {"y^2", "0", "0"}, {"z", "0", "0"}}, "car"), []float64{5, 2, 0}},
+		// A hydrostatic stress -p has the divergence -grad p
+		{[]float64{2, 1, 0.5}, NewTensorField([3][3]string{{"-r^2", "0", "0"}, {"0", "-r^2", "0"}, {"0", "0", "-r^2"}}, "sph"), []float64{-4, 0, 0}},
+		{[]float64{2, 1, 0.5}, NewTensorField([3][3]string{{"-z*r", "0", "0"}, {"0", "-z*r", "0"}, {"0", "0", "-z*r"}}, "cyl"), []float64{-0.5, 0, -2}},
+	}
+	for _, v := range tests {
+		if got := v.t.Div(v.point); !almostEqual(got, v.exp, 1e-6) {
+			t.Error("Test failed: {", v.point, v.t, " } inputted, expected {", v.exp, "} and got {", got, "}")
+		}
+	}
+	// The divergence with the terms of the unit vectors agrees with the divergence through cartesian coordinates
+	e := [3][3]string{{"r^2", "sin(phi)", "r*z"}, {"r*cos(phi)", "z^2", "1"}, {"r", "phi", "r*z^2"}}
+	f := [3][3]string{{"r^2", "sin(phi)", "r*theta"}, {"r*cos(phi)", "theta^2", "1"}, {"r", "phi", "r*cos(theta)"}}
+	for _, c := range [][]float64{{1.3, 0.7, 2}, {0.5, 2, -1}} {
+		for _, T := range []tensorField{NewTensorField(e, "cyl"), NewTensorField(f, "sph")} {
+			if got, exp := T.Div(c), divTensorCartesian(T.eval, c, T.coordsys); !almostEqual(got, exp, 1e-5) {
+				t.Error("Test failed: {", c, T, " } inputted, expected {", exp, "} and got {", got, "}")
+			}
+		}
+	}
+}
+
+func TestTensorDivSingular(t *testing.T) {
+	T := NewTensorField([3][3]string{{"r", "0", "0"}, {"0", "r", "0"}, {"0", "0", "r"}}, "cyl")
+	if _, err := T.TryDiv([]float64{0, 1, 1}); err != ErrSingularPoint {
+		t.Error("Test failed: { r=0 } inputted, expected {", ErrSingularPoint, "} and got {", err, "}")
+	}
+	// r times the identity is sqrt(x^2+y^2) times the identity, whose divergence is the unit vector along r
+	got, err := NewTensorField(T.expressions, "cyl", WithSingularity(SingularityLimit)).TryDiv([]float64{0, 1, 1})
+	if exp := []float64{1, 0, 0}; err != nil || !almostEqual(got, exp, 1e-4) {
+		t.Error("Test failed: { r=0 } inputted, expected {", exp, "} and got {", got, err, "}")
+	}
+}
+
+func TestTensorAlgebra(t *testing.T) {
+	T := NewTensorField([3][3]string{{"x", "y", "0"}, {"z", "2", "x*y"}, {"1", "0", "z"}}, "car")
+	var tests = []struct {
+		got string
+		exp string
+	}{
+		{T.String(), "((x, y, 0), (z, 2, x*y), (1, 0, z))"},
+		{T.Trace().String(), "x+2+z"},
+		{T.Transpose().String(), "((x, z, 1), (y, 2, 0), (0, x*y, z))"},
+		{T.Symmetric().String(), "((x, (y+z)/2, 0.5), ((z+y)/2, 2, x*y/2), (0.5, x*y/2, z))"},
+		{T.Antisymmetric().String(), "((0, (y-z)/2, -0.5), ((z-y)/2, 0, x*y/2), (0.5, -(x*y)/2, 0))"},
+		{T.Dot(NewVectorField("1", "0", "0", "car")).String(), "(x, z, 1)"},
+		{T.Transpose().Dot(NewVectorField("1", "0", "0", "car")).String(), "(x, y, 0)"},
+		{T.Contract(NewVectorField("0", "1", "0", "car"), NewVectorField("0", "0", "1", "car")).String(), "x*y"},
+	}
+	for _, v := range tests {
+		if v.got != v.exp {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+	// The contraction with a field in other coordinates has the same value at the same point
+	v := NewVectorField("r", "1", "0", "cyl")
+	for _, c := range [][]float64{{1, 0.5, 2}, {-2, 1, 0.3}} {
+		y := fromCartesian(c, "cyl")
+		w := vecToCartesian(v.eval(y), y, "cyl")
+		exp := make([]float64, 3)
+		for i, row := range T.eval(c) {
+			exp[i] = row[0]*w[0] + row[1]*w[1] + row[2]*w[2]
+		}
+		if got := T.Dot(v).eval(c); !almostEqual(got, exp, 1e-9) {
+			t.Error("Test failed: {", c, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+	// A field with a bound parameter keeps it
+	S := NewTensorField([3][3]string{{"k", "0", "0"}, {"0", "k", "0"}, {"0", "0", "k"}}, "sph", WithParams("k")).Bind("k", 2)
+	if got := S.Trace().eval([]float64{1, 1, 1}); math.Abs(got-6) > 1e-12 {
+		t.Error("Test failed: { k=2 } inputted, expected {", 6, "} and got {", got, "}")
+	}
+}
@@ -0,0 +1,47 @@
+package vcalc
+
+// Returns the closed curves around the patches of surface, one for each side of the rectangle of the parameters u
+// and v, going around it from u0, v0 to u1, v0 to u1, v1 to u0, v1 so that they turn about the normal of the patch
+// Each curve goes from the parameter 0 to 1. The sides a patch shares with another patch cancel in a line integral
+func (s surface) edges() []curve {
+	var res []curve
+	for _, p := range s.patches {
+		p := p
+		side := func(u0, v0, u1, v1 float64) curve {
+			return NewCurveFunc(func(t float64) []float64 {
+				return s.point(p, u0+t*(u1-u0), v0+t*(v1-v0))
+			}, p.coordsys)
+		}
+		res = append(res,
+			side(p.u[0], p.v[0], p.u[1], p.v[0]),
+			side(p.u[1], p.v[0], p.u[1], p.v[1]),
+			side(p.u[1], p.v[1], p.u[0], p.v[1]),
+			side(p.u[0], p.v[1], p.u[0], p.v[0]))
+	}
+	return res
+}
+
+// Calculates both sides of the divergence theorem for vectorField and region, the integral of the divergence over
+// region and the flux through its boundary with the normal pointing outwards
+// Returns the volume integral, the flux and their difference, which is only from numerical error when the field
+// is defined correctly and has no singular point in region
+// Panics for a region given by an indicator, whose boundary is not known
+func (v vectorField) VerifyDivergence(g region, opts ...IntegralOption) (float64, float64, float64) {
+	boundary := g.Boundary()
+	volume, _, _ := volumeIntegral(v.Div, v.coordsys, g, opts)
+	flux, _ := flux(v.eval, v.coordsys, boundary, opts)
+	return volume, flux, volume - flux
+}
+
+// Calculates both sides of Stokes' theorem for vectorField and surface, the flux of the rotation through surface and
+// the line integral around its boundary, which turns about the normal of the surface
+// Returns the flux, the line integral and their difference like VerifyDivergence. Both sides are 0 for a closed surface
+func (v vectorField) VerifyStokes(s surface, opts ...IntegralOption) (float64, float64, float64) {
+	rot, _ := flux(v.Rot, v.coordsys, s, opts)
+	var line float64
+	for _, c := range s.edges() {
+		l, _ := lineIntegral(v.eval, v.coordsys, c, 0, 1, opts)
+		line += l
+	}
+	return rot, line, rot - line
+}
@@ -0,0 +1,56 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVerifyDivergence(t *testing.T) {
+	var tests = []struct {
+		v   vectorField
+		g   region
+		exp float64
+	}{
+		{NewVectorField("x^2", "y*z", "x*z", "car"), NewBoxRegion([]float64{0, 0, 0}, []float64{1, 2, 3}), 18},
+		{NewVectorField("r", "0", "z", "cyl"), NewCylindricalShell(1, 2, 0, 2*math.Pi, 0, 1), 9 * math.Pi},
+		{NewVectorField("r^2", "0", "0", "sph"), NewSphericalSector(0, 1, 0, math.Pi, 0, 2*math.Pi), 4 * math.Pi},
+		// A part of a shell, where the faces at the limits of the angles add to the flux
+		{NewVectorField("0", "r*sin(phi)", "0", "cyl"), NewCylindricalShell(1, 2, 0, math.Pi/2, 0, 1), 1.5},
+		{NewVectorField("x", "y", "z", "car"), NewSphericalSector(0, 1, 0, math.Pi/2, 0, math.Pi), math.Pi},
+	}
+	for _, v := range tests {
+		volume, flux, diff := v.v.VerifyDivergence(v.g)
+		if math.Abs(volume-v.exp) > 1e-5 || math.Abs(flux-v.exp) > 1e-5 || math.Abs(diff) > 1e-5 {
+			t.Error("Test failed: {", v.v, v.g.coordsys, " } inputted, expected {", v.exp, "} and got {", volume, flux, diff, "}")
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Test failed: { indicator } inputted, expected a panic")
+		}
+	}()
+	NewVectorField("x", "0", "0", "car").VerifyDivergence(NewRegion("x < 1", "car", []float64{0, 0, 0}, []float64{1, 1, 1}))
+}
+
+func TestVerifyStokes(t *testing.T) {
+	var tests = []struct {
+		v   vectorField
+		s   surface
+		exp float64
+	}{
+		{NewVectorField("-y", "x", "0", "car"), NewPlanePatch([]float64{0, 0, 0}, []float64{1, 0, 0}, []float64{0, 2, 0}), 4},
+		{NewVectorField("-y", "x", "0", "car"), NewSurface("u", "v", "0", "cyl", 0, 1, 0, 2*math.Pi), 2 * math.Pi},
+		// The upper half of the unit sphere has the unit circle as boundary
+		{NewVectorField("-y", "x", "x*z", "car"), NewSurface("1", "u", "v", "sph", 0, math.Pi/2, 0, 2*math.Pi), 2 * math.Pi},
+		{NewVectorField("0", "r^2", "0", "cyl"), NewSurface("u", "v", "1", "cyl", 0, 2, 0, math.Pi), 8 * math.Pi},
+		// Both sides are 0 for a closed surface
+		{NewVectorField("y*z", "x^2", "z*y", "car"), NewBox([]float64{0, 0, 0}, []float64{1, 1, 1}), 0},
+		{NewVectorField("y*z", "x^2", "z*y", "car"), NewSphere([]float64{0, 0, 0}, 1), 0},
+	}
+	for _, v := range tests {
+		rot, line, diff := v.v.VerifyStokes(v.s)
+		if math.Abs(rot-v.exp) > 1e-5 || math.Abs(line-v.exp) > 1e-5 || math.Abs(diff) > 1e-5 {
+			t.Error("Test failed: {", v.v, " } inputted, expected {", v.exp, "} and got {", rot, line, diff, "}")
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package vcalc
+
+// The name of the time, which can be used in the expressions of every field except an N-dimensional scalar field
+const timeName = "t"
+
+// Returns the partial derivative of f with respect to the time at the time t, by a central difference of each value
+func partialT(f func(t float64) []float64, t float64) []float64 {
+	h := 0.0001
+	a, b := f(t+h), f(t-h)
+	res := make([]float64, len(a))
+	for i := range a {
+		res[i] = (a[i] - b[i]) / (2 * h)
+	}
+	return res
+}
+
+// Returns a copy of scalarField at the time t, where it is evaluated and its operators are calculated
+// A field is at the time 0 until At is called
+func (s scalarField) At(t float64) scalarField {
+	s.opts.time = t
+	return s
+}
+
+// Returns the partial derivative with respect to the time of scalarField at point c and the time t
+func (s scalarField) PartialT(c []float64, t float64) float64 {
+	return partialT(func(t float64) []float64 {
+		return []float64{s.At(t).eval(c)}
+	}, t)[0]
+}
+
+// Returns a copy of vectorField at the time t, where it is evaluated and its operators are calculated
+func (v vectorField) At(t float64) vectorField {
+	v.opts.time = t
+	return v
+}
+
+// Returns the partial derivative with respect to the time of each component of vectorField at point c and the time t
+func (v vectorField) PartialT(c []float64, t float64) []float64 {
+	return partialT(func(t float64) []float64 {
+		return v.At(t).eval(c)
+	}, t)
+}
+
+// Returns a copy of scalarField2D at the time t, where it is evaluated and its operators are calculated
+func (s scalarField2D) At(t float64) scalarField2D {
+	s.opts.time = t
+	return s
+}
+
+// Returns the partial derivative with respect to the time of scalarField2D at point c and the time t
+func (s scalarField2D) PartialT(c []float64, t float64) float64 {
+	return partialT(func(t float64) []float64 {
+		return []float64{s.At(t).eval(c)}
+	}, t)[0]
+}
+
+// Returns a copy of vectorField2D at the time t, where it is evaluated and its operators are calculated
+func (v vectorField2D) At(t float64) vectorField2D {
+	v.opts.time = t
+	return v
+}
+
+// Returns the partial derivative with respect to the time of each component of vectorField2D at point c and the time t
+func (v vectorField2D) PartialT(c []float64, t float64) []float64 {
+	return partialT(func(t float64) []float64 {
+		return v.At(t).eval(c)
+	}, t)
+}
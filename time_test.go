@@ -0,0 +1,97 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAt(t *testing.T) {
+	var tests = []struct {
+		got float64
+		exp float64
+	}{
+		{NewScalarField("x*t", "car").At(2).fn(3, 0, 0), 6},
+		{NewScalarField("x*t", "car").fn(3, 0, 0), 0},
+		{NewScalarField("r*exp(-t)", "sph").At(math.Log(2)).Grad([]float64{1, 1, 1})[0], 0.5},
+		{NewVectorField("r*t", "0", "z", "cyl").At(3).Div([]float64{1, 0, 0}), 7},
+		{NewScalarField2D("x^2*cos(t)", "car2").At(math.Pi).Laplacian([]float64{1, 1}), -2},
+		{NewVectorField2D("-y*t", "x*t", "car2").At(0.5).Curl([]float64{1, 2}), 1},
+		{NewScalarField("q*t", "car", WithParams("q")).Bind("q", 2).At(4).fn(0, 0, 0), 8},
+	}
+	for _, v := range tests {
+		if math.Abs(v.got-v.exp) > 1e-6 {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestPartialT(t *testing.T) {
+	var tests = []struct {
+		got []float64
+		exp []float64
+	}{
+		{[]float64{NewScalarField("x*t^2", "car").PartialT([]float64{1, 0, 0}, 3)}, []float64{6}},
+		{[]float64{NewScalarField("r", "sph").PartialT([]float64{1, 0, 0}, 3)}, []float64{0}},
+		{NewVectorField("sin(t)", "r*t", "0", "cyl").PartialT([]float64{2, 0, 0}, 0), []float64{1, 2, 0}},
+		{[]float64{NewScalarField2D("exp(t*x)", "car2").PartialT([]float64{1, 0}, 0)}, []float64{1}},
+		{NewVectorField2D("t", "-t^2", "polar").PartialT([]float64{1, 0}, 1), []float64{1, -2}},
+	}
+	for _, v := range tests {
+		if !almostEqual(v.got, v.exp, 1e-6) {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+	if got := NewScalarField("x*t^2", "car").Diff("t").String(); got != "x*(2*t)" {
+		t.Error("Test failed: { t } inputted, expected {", "x*(2*t)", "} and got {", got, "}")
+	}
+	if got := NewScalarField("x*t", "car").Substitute(map[string]Expression{"t": Expr("2")}).String(); got != "x*2" {
+		t.Error("Test failed: { t=2 } inputted, expected {", "x*2", "} and got {", got, "}")
+	}
+}
+
+func TestMaxwell(t *testing.T) {
+	// A plane wave satisfies rot E = -dB/dt and rot B = dE/dt in units where the speed of light is 1
+	E := NewVectorField("0", "cos(x-t)", "0", "car")
+	B := NewVectorField("0", "0", "cos(x-t)", "car")
+	for _, p := range [][]float64{{0, 0, 0, 0}, {1, 2, 3, 0.5}, {-2, 1, 0, 4}} {
+		c, time := p[:3], p[3]
+		dB, dE := B.PartialT(c, time), E.PartialT(c, time)
+		if got, exp := E.At(time).Rot(c), []float64{-dB[0], -dB[1], -dB[2]}; !almostEqual(got, exp, 1e-6) {
+			t.Error("Test failed: {", p, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+		if got := B.At(time).Rot(c); !almostEqual(got, dE, 1e-6) {
+			t.Error("Test failed: {", p, " } inputted, expected {", dE, "} and got {", got, "}")
+		}
+	}
+	// A charge decaying uniformly satisfies the continuity equation div J = -drho/dt
+	rho := NewScalarField("exp(-t)", "sph")
+	J := NewVectorField("r*exp(-t)/3", "0", "0", "sph")
+	for _, p := range [][]float64{{1, 1, 1, 0}, {2, 0.5, 3, 1}} {
+		c, time := p[:3], p[3]
+		if got, exp := J.At(time).Div(c), -rho.PartialT(c, time); math.Abs(got-exp) > 1e-6 {
+			t.Error("Test failed: {", p, " } inputted, expected {", exp, "} and got {", got, "}")
+		}
+	}
+}
+
+func TestTimePanics(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    func()
+	}{
+		{"parameter t", func() { NewScalarField("x", "car", WithParams("t")) }},
+		{"function t", func() { NewFuncRegistry().RegisterFunc("t", 1, sumOf) }},
+		{"different times", func() { NewScalarField("t", "car").At(1).Add(NewScalarField("t", "car")) }},
+		{"time in N dimensions", func() { NewScalarFieldN("x*t", []string{"x"}) }},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic")
+				}
+			}()
+			v.f()
+		}()
+	}
+}
@@ -0,0 +1,327 @@
+package vcalc
+
+import (
+	"math"
+)
+
+// A TraceMethod is the integrator TraceLine follows a field line with
+type TraceMethod int
+
+const (
+	// TraceDormandPrince, the default, takes steps of the adaptive Dormand-Prince method of order 5, whose length
+	// is chosen so that the error of each step is within the tolerance
+	TraceDormandPrince TraceMethod = iota
+	// TraceRK4 takes steps of the same length with the classical Runge-Kutta method of order 4
+	TraceRK4
+)
+
+// A StopReason tells why TraceLine stopped following a field line
+type StopReason int
+
+const (
+	// StopLength means the line reached the largest length
+	StopLength StopReason = iota
+	// StopBounds means the line left the bounding box, its last point is on the boundary of the box
+	StopBounds
+	// StopStagnation means the field became smaller than the stagnation threshold or the line ran into a point
+	// where the field vanishes
+	StopStagnation
+	// StopSingular means the field could not be evaluated or the line ran into a point where the field grows
+	// without bound, like a point charge
+	StopSingular
+	// StopSteps means the line took the largest number of steps
+	StopSteps
+)
+
+// Returns the name of the stop reason
+func (r StopReason) String() string {
+	switch r {
+	case StopLength:
+		return "length"
+	case StopBounds:
+		return "bounds"
+	case StopStagnation:
+		return "stagnation"
+	case StopSingular:
+		return "singular"
+	default:
+		return "steps"
+	}
+}
+
+// Settings of TraceLine that are given as options
+type traceOptions struct {
+	method       TraceMethod
+	step, tol    float64
+	lower, upper []float64
+	maxLength    float64
+	minField     float64
+	maxSteps     int
+	backward     bool
+}
+
+// A TraceOption changes a setting of TraceLine, like the integrator or when the line stops
+type TraceOption func(*traceOptions)
+
+// Returns an option choosing the integrator of TraceLine, the default is TraceDormandPrince
+func WithMethod(method TraceMethod) TraceOption {
+	return func(o *traceOptions) {
+		o.method = method
+	}
+}
+
+// Returns an option setting the length of the steps of TraceRK4 and the first step of TraceDormandPrince, the
+// default is 0.01
+func WithStep(h float64) TraceOption {
+	return func(o *traceOptions) {
+		if h <= 0 {
+			panic("The step of a field line must be larger than zero")
+		}
+		o.step = h
+	}
+}
+
+// Returns an option setting the error of each step of TraceDormandPrince, the default is 1e-8
+func WithTraceTolerance(tol float64) TraceOption {
+	return func(o *traceOptions) {
+		if tol <= 0 {
+			panic("The tolerance of a field line must be larger than zero")
+		}
+		o.tol = tol
+	}
+}
+
+// Returns an option stopping the line when it leaves the box from the cartesian corner lower to upper, by default
+// the line is not bounded
+func WithBounds(lower, upper []float64) TraceOption {
+	checkCenter(lower)
+	checkCenter(upper)
+	return func(o *traceOptions) {
+		o.lower, o.upper = lower, upper
+	}
+}
+
+// Returns an option setting the largest length of the line, the default is 10
+func WithMaxLength(length float64) TraceOption {
+	return func(o *traceOptions) {
+		if length <= 0 {
+			panic("The largest length of a field line must be larger than zero")
+		}
+		o.maxLength = length
+	}
+}
+
+// Returns an option stopping the line where the magnitude of the field is smaller than min, the default is 1e-12
+func WithStagnation(min float64) TraceOption {
+	return func(o *traceOptions) {
+		if min <= 0 {
+			panic("The threshold of the stagnation of a field line must be larger than zero")
+		}
+		o.minField = min
+	}
+}
+
+// Returns an option setting the largest number of steps of the line, the default is 100000
+func WithMaxSteps(n int) TraceOption {
+	return func(o *traceOptions) {
+		if n <= 0 {
+			panic("The largest number of steps of a field line must be larger than zero")
+		}
+		o.maxSteps = n
+	}
+}
+
+// Returns an option following the line against the direction of the field
+func WithBackward() TraceOption {
+	return func(o *traceOptions) {
+		o.backward = true
+	}
+}
+
+// The coefficients of the stages of the Dormand-Prince method, where stage i is at the point plus the sum of
+// dormandPrince[i][j] times the direction of stage j
+var dormandPrince = [][]float64{
+	{},
+	{1.0 / 5},
+	{3.0 / 40, 9.0 / 40},
+	{44.0 / 45, -56.0 / 15, 32.0 / 9},
+	{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+	{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+	{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+}
+
+// The weights of the stages of the Dormand-Prince method of order 5 minus those of order 4, the error of a step
+var dormandPrinceError = []float64{
+	35.0/384 - 5179.0/57600, 0, 500.0/1113 - 7571.0/16695, 125.0/192 - 393.0/640,
+	-2187.0/6784 + 92097.0/339200, 11.0/84 - 187.0/2100, -1.0 / 40,
+}
+
+// Returns the unit vector along vectorField at the cartesian point p, against it if backward is true, and the
+// magnitude of the field, or false if the field is not finite at p
+func (v vectorField) direction(p []float64, backward bool) ([]float64, float64, bool) {
+	c := fromCartesian(p, v.coordsys)
+	F := vecToCartesian(v.eval(c), c, v.coordsys)
+	norm := math.Sqrt(F[0]*F[0] + F[1]*F[1] + F[2]*F[2])
+	if math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return nil, norm, false
+	}
+	if backward {
+		norm = -norm
+	}
+	return []float64{F[0] / norm, F[1] / norm, F[2] / norm}, math.Abs(norm), norm != 0
+}
+
+// Returns the point p plus h times the sum of weights[i] times d[i]
+func advance(p []float64, h float64, weights []float64, d [][]float64) []float64 {
+	res := append([]float64(nil), p...)
+	for i, w := range weights {
+		for k := range res {
+			res[k] += h * w * d[i][k]
+		}
+	}
+	return res
+}
+
+// Returns true if the direction e turned more than a right angle from d, which a field line only does within a step
+// when it runs past a point where the field vanishes or is singular
+func turned(d, e []float64) bool {
+	return d[0]*e[0]+d[1]*e[1]+d[2]*e[2] < 0
+}
+
+// Returns the point after a step of length h from the cartesian point p with the classical Runge-Kutta method, or
+// false if the field is not finite or turns around at a stage
+func (v vectorField) rk4(p []float64, h float64, backward bool) ([]float64, bool) {
+	d := make([][]float64, 4)
+	var ok bool
+	for i, w := range [][]float64{{}, {0.5}, {0, 0.5}, {0, 0, 1}} {
+		if d[i], _, ok = v.direction(advance(p, h, w, d), backward); !ok || turned(d[0], d[i]) {
+			return nil, false
+		}
+	}
+	return advance(p, h, []float64{1.0 / 6, 1.0 / 3, 1.0 / 3, 1.0 / 6}, d), true
+}
+
+// Returns the point after a step of length h from the cartesian point p with the Dormand-Prince method and the
+// largest error of its coordinates, or false if the field is not finite or turns around at a stage
+func (v vectorField) dormandPrince(p []float64, h float64, backward bool) ([]float64, float64, bool) {
+	d := make([][]float64, len(dormandPrince))
+	var ok bool
+	for i, w := range dormandPrince {
+		if d[i], _, ok = v.direction(advance(p, h, w, d), backward); !ok || turned(d[0], d[i]) {
+			return nil, 0, false
+		}
+	}
+	var err float64
+	for _, e := range advance(make([]float64, 3), h, dormandPrinceError, d) {
+		err = math.Max(err, math.Abs(e))
+	}
+	return advance(p, h, dormandPrince[6], d), err, true
+}
+
+// Returns true if the cartesian point p is inside the bounds of o, or o has no bounds
+func (o traceOptions) inside(p []float64) bool {
+	if o.lower == nil {
+		return true
+	}
+	for k := range p {
+		if p[k] < o.lower[k] || p[k] > o.upper[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the point where the line from the cartesian point p inside the bounds of o to q outside leaves the box
+func (o traceOptions) exit(p, q []float64) []float64 {
+	t := 1.0
+	for k := range p {
+		if q[k] > o.upper[k] {
+			t = math.Min(t, (o.upper[k]-p[k])/(q[k]-p[k]))
+		} else if q[k] < o.lower[k] {
+			t = math.Min(t, (o.lower[k]-p[k])/(q[k]-p[k]))
+		}
+	}
+	return []float64{p[0] + t*(q[0]-p[0]), p[1] + t*(q[1]-p[1]), p[2] + t*(q[2]-p[2])}
+}
+
+// Follows the field line of vectorField from the point start in its coordinates, solving dx/ds = F/|F| for the
+// cartesian point x along the arc length s, and returns the points of the line in the coordinates of vectorField
+// and why it stopped
+// The line stops at the largest length, when it leaves the bounds, when the field is smaller than the stagnation
+// threshold, or when the field is not finite. A line running into a point where the field vanishes or grows without
+// bound turns around there, which stops it as stagnation when the field fell and as singular when it grew
+func (v vectorField) TraceLine(start []float64, opts ...TraceOption) ([][]float64, StopReason) {
+	if len(start) != 3 {
+		panic("Too many or too few points coordinates given")
+	}
+	o := traceOptions{method: TraceDormandPrince, step: 0.01, tol: 1e-8, maxLength: 10, minField: 1e-12, maxSteps: 100000}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := toCartesian(start, v.coordsys)
+	points := [][]float64{fromCartesian(p, v.coordsys)}
+	if !o.inside(p) {
+		return points, StopBounds
+	}
+	d, norm, ok := v.direction(p, o.backward)
+	if !ok && norm == 0 || ok && norm < o.minField {
+		return points, StopStagnation
+	} else if !ok {
+		return points, StopSingular
+	}
+	// halt returns why the line stopped at p, where a step along d runs past a point where the field vanishes or
+	// is singular, as stagnation if the field falls a little further along d and as singular if it grows
+	halt := func(p, d []float64, h float64) StopReason {
+		if _, next, ok := v.direction(advance(p, h/100, []float64{1}, [][]float64{d}), o.backward); ok && next < norm {
+			return StopStagnation
+		}
+		return StopSingular
+	}
+	var length float64
+	h := o.step
+	for steps := 0; steps < o.maxSteps; steps++ {
+		if o.maxLength-length <= 1e-12*o.maxLength {
+			return points, StopLength
+		}
+		h = math.Min(h, o.maxLength-length)
+		var q []float64
+		used := h
+		if o.method == TraceRK4 {
+			if q, ok = v.rk4(p, h, o.backward); !ok {
+				return points, halt(p, d, h)
+			}
+		} else {
+			for {
+				var err float64
+				q, err, ok = v.dormandPrince(p, h, o.backward)
+				if ok && err <= o.tol {
+					used = h
+					h *= math.Min(5, math.Max(0.2, 0.9*math.Pow(o.tol/math.Max(err, 1e-300), 0.2)))
+					break
+				}
+				h /= 4
+				if h < 1e-12*math.Max(1, math.Sqrt(p[0]*p[0]+p[1]*p[1]+p[2]*p[2])) {
+					// The step can not be made small enough where the direction of the field jumps
+					return points, halt(p, d, h)
+				}
+			}
+		}
+		if !o.inside(q) {
+			return append(points, fromCartesian(o.exit(p, q), v.coordsys)), StopBounds
+		}
+		e, next, ok := v.direction(q, o.backward)
+		switch {
+		case !ok && next == 0 || ok && next < o.minField:
+			return append(points, fromCartesian(q, v.coordsys)), StopStagnation
+		case !ok:
+			return points, StopSingular
+		case turned(d, e):
+			// The line went past a point where the field vanishes or is singular, so q is not on it
+			return points, halt(p, d, used)
+		}
+		points = append(points, fromCartesian(q, v.coordsys))
+		p, d, norm = q, e, next
+		length += used
+	}
+	return points, StopSteps
+}
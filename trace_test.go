@@ -0,0 +1,106 @@
+package vcalc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTraceLine(t *testing.T) {
+	circle := NewVectorField("-y", "x", "0", "car")
+	methods := []TraceMethod{TraceDormandPrince, TraceRK4}
+	var tests = []struct {
+		name   string
+		v      vectorField
+		start  []float64
+		opts   []TraceOption
+		end    []float64
+		reason StopReason
+	}{
+		// Around the unit circle once, back to the start
+		{"circle", circle, []float64{1, 0, 0}, []TraceOption{WithMaxLength(2 * math.Pi)}, []float64{1, 0, 0}, StopLength},
+		{"half circle", circle, []float64{1, 0, 0}, []TraceOption{WithMaxLength(math.Pi)}, []float64{-1, 0, 0}, StopLength},
+		{"backward", circle, []float64{1, 0, 0}, []TraceOption{WithMaxLength(math.Pi / 2), WithBackward()}, []float64{0, -1, 0}, StopLength},
+		// A uniform field leaves the box where it crosses its side
+		{"bounds", NewVectorField("1", "0.5", "0", "car"), []float64{0, 0, 0},
+			[]TraceOption{WithBounds([]float64{-1, -1, -1}, []float64{1, 1, 1})}, []float64{1, 0.5, 0}, StopBounds},
+		{"outside", NewVectorField("1", "0", "0", "car"), []float64{2, 0, 0},
+			[]TraceOption{WithBounds([]float64{-1, -1, -1}, []float64{1, 1, 1})}, []float64{2, 0, 0}, StopBounds},
+		// The field of the cylindrical unit vector phi is the circle in cylindrical coordinates
+		{"cylindrical", NewVectorField("0", "1", "0", "cyl"), []float64{1, 0, 2}, []TraceOption{WithMaxLength(math.Pi / 2)},
+			[]float64{1, math.Pi / 2, 2}, StopLength},
+		// A spherical radial field is a straight line away from the origin
+		{"spherical", NewVectorField("1/r^2", "0", "0", "sph"), []float64{1, 1, 1}, []TraceOption{WithMaxLength(2)},
+			[]float64{3, 1, 1}, StopLength},
+		// Against the field of a point charge the line runs into the charge
+		{"charge", NewVectorField("1/r^2", "0", "0", "sph"), []float64{1, 1, 1}, []TraceOption{WithBackward()},
+			nil, StopSingular},
+		// The field vanishes at the origin, where the line stops
+		{"sink", NewVectorField("-x", "-y", "-z", "car"), []float64{1, 0, 0}, []TraceOption{WithStagnation(1e-6)},
+			nil, StopStagnation},
+		{"zero", NewVectorField("x", "y", "z", "car"), []float64{0, 0, 0}, nil, []float64{0, 0, 0}, StopStagnation},
+		{"steps", circle, []float64{1, 0, 0}, []TraceOption{WithMaxSteps(3), WithMethod(TraceRK4)}, []float64{math.Cos(0.03), math.Sin(0.03), 0}, StopSteps},
+	}
+	for _, test := range tests {
+		for _, method := range methods {
+			points, reason := test.v.TraceLine(test.start, append([]TraceOption{WithMethod(method)}, test.opts...)...)
+			end := points[len(points)-1]
+			if reason != test.reason || test.end != nil && !almostEqual(end, test.end, 1e-6) {
+				t.Error("Test failed: {", test.name, method, " } inputted, expected {", test.end, test.reason, "} and got {", end, reason, "}")
+			}
+		}
+	}
+}
+
+func TestTraceLineShape(t *testing.T) {
+	// The points of a field line of the circle field stay on the circle, and the line into a sink or a charge ends
+	// near the point where it stops
+	circle := NewVectorField("-y", "x", "0", "car")
+	for _, method := range []TraceMethod{TraceDormandPrince, TraceRK4} {
+		points, _ := circle.TraceLine([]float64{2, 0, 1}, WithMethod(method), WithMaxLength(4*math.Pi))
+		for _, p := range points {
+			if r := math.Hypot(p[0], p[1]); math.Abs(r-2) > 1e-6 || p[2] != 1 {
+				t.Error("Test failed: {", method, " } inputted, expected {", 2, 1, "} and got {", r, p[2], "}")
+			}
+		}
+		var ends = []struct {
+			v     vectorField
+			start []float64
+			opts  []TraceOption
+		}{
+			{NewVectorField("-x", "-y", "-z", "car"), []float64{1, 1, 1}, nil},
+			{NewVectorField("1/r^2", "0", "0", "sph"), []float64{1, 1, 1}, []TraceOption{WithBackward()}},
+		}
+		for _, e := range ends {
+			points, _ := e.v.TraceLine(e.start, append(e.opts, WithMethod(method), WithStagnation(1e-6))...)
+			end := toCartesian(points[len(points)-1], e.v.coordsys)
+			if d := math.Sqrt(end[0]*end[0] + end[1]*end[1] + end[2]*end[2]); d > 0.02 {
+				t.Error("Test failed: {", method, e.v.coordsys, " } inputted, expected {", 0, "} and got {", d, "}")
+			}
+		}
+	}
+}
+
+func TestTraceOptionsPanics(t *testing.T) {
+	circle := NewVectorField("-y", "x", "0", "car")
+	var tests = []struct {
+		name string
+		opt  TraceOption
+	}{
+		{"step", WithStep(0)},
+		{"tolerance", WithTraceTolerance(-1e-8)},
+		{"length", WithMaxLength(0)},
+		{"negative length", WithMaxLength(-1)},
+		{"stagnation", WithStagnation(0)},
+		{"steps", WithMaxSteps(0)},
+	}
+	for _, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Test failed: {", v.name, " } inputted, expected a panic and got none")
+				}
+			}()
+			circle.TraceLine([]float64{1, 0, 0}, v.opt)
+		}()
+	}
+}
@@ -3,18 +3,17 @@
 package vcalc
 
 import (
-	"fmt"
 	"math"
 	"regexp"
-	"strconv"
-	"strings"
 )
 
 // A scalar field has a mathematical expression as string and
 // a coordinate system defined as "car" for cartesina, "cyl" for cylinder, "sph" for spherical
 type scalarField struct {
 	expression string
+	tree       *node
 	coordsys   string
+	opts       fieldOptions
 }
 
 // A vector field has a mathematical expression for each coordinate in 3-dimensional space and
@@ -23,247 +22,303 @@ type vectorField struct {
 	expressionCoord1 string
 	expressionCoord2 string
 	expressionCoord3 string
+	treeCoord1       *node
+	treeCoord2       *node
+	treeCoord3       *node
 	coordsys         string
+	opts             fieldOptions
 }
 
-// Returns a new scalar field
-func NewScalarField(expression string, coordsys string) scalarField {
+// Returns a new scalar field, the options opts change the default settings of the field
+func NewScalarField(expression string, coordsys string, opts ...FieldOption) scalarField {
 	s := scalarField{}
-	s.expression = expression
 	s.coordsys = coordsys
-	checkCoords(expression, coordsys)
+	s.opts = newFieldOptions(opts)
+	checkCoords(expression, coordsys, s.opts)
+	return s.withExpression(expression)
+}
+
+// Returns scalarField with the expression e and its tree, which is parsed once here and calculated at every point
+func (s scalarField) withExpression(e string) scalarField {
+	s.expression = e
+	s.tree = s.opts.mustParse(e)
 	return s
 }
 
-// Returns a new scalar field
-func NewVectorField(e1, e2, e3, coordsys string) vectorField {
+// Returns the partial derivative of scalarField with respect to the coordinate, parameter or time t coord as a new scalar field
+// The derivative is found symbolically from the expression
+func (s scalarField) Diff(coord string) scalarField {
+	if !isCoord(coord, s.coordsys) && !s.opts.isParam(coord) && coord != timeName {
+		panic(coord + " is neither a coordinate of " + s.coordsys + ", a parameter of the field nor the time t")
+	}
+	return s.withExpression(s.tree.diff(coord).String())
+}
+
+// Returns scalarField with its expression simplified, which has the same value wherever the expression is defined
+// Numbers are folded, like terms collected, powers of the same base merged and identities like sin(u)^2+cos(u)^2=1 used
+func (s scalarField) Simplify() scalarField {
+	return s.withExpression(s.tree.simplify().String())
+}
+
+// Returns the expression of scalarField as canonical text, which gives the same field when used as expression
+func (s scalarField) String() string {
+	return s.tree.String()
+}
+
+// Returns the expression of scalarField as LaTeX, where theta, phi and other greek names are written as letters
+func (s scalarField) LaTeX() string {
+	return s.tree.latex()
+}
+
+// Returns a new vector field, the options opts change the default settings of the field
+func NewVectorField(e1, e2, e3, coordsys string, opts ...FieldOption) vectorField {
 	v := vectorField{}
-	v.expressionCoord1 = e1
-	v.expressionCoord2 = e2
-	v.expressionCoord3 = e3
 	v.coordsys = coordsys
-	checkCoords(e1+"+"+e2+"+"+e3, coordsys)
+	v.opts = newFieldOptions(opts)
+	for _, e := range []string{e1, e2, e3} {
+		checkCoords(e, coordsys, v.opts)
+	}
+	return v.withExpressions(e1, e2, e3)
+}
+
+// Returns vectorField with the expressions e1, e2, e3 and their trees like withExpression of scalarField
+func (v vectorField) withExpressions(e1, e2, e3 string) vectorField {
+	v.expressionCoord1, v.expressionCoord2, v.expressionCoord3 = e1, e2, e3
+	v.treeCoord1, v.treeCoord2, v.treeCoord3 = v.opts.mustParse(e1), v.opts.mustParse(e2), v.opts.mustParse(e3)
 	return v
 }
 
-// Checks if user has used right coordinate names, panics if not
-func checkCoords(expression string, coordsys string) {
-	switch coordsys {
-	case "car":
-		if regexp.MustCompile(`[^q]r`).MatchString(expression) ||
-			regexp.MustCompile(`theta`).MatchString(expression) ||
-			regexp.MustCompile(`phi`).MatchString(expression) {
-			panic("Insufficient coordinate names given, the following coordinate names are allowed together: (x,y,z) for cartesian coordinates, (r,phi,z) for cylinder coordinates and (r,theta,phi) for spherical coordinates")
-		}
-	case "cyl":
-		if regexp.MustCompile(`x[^p]`).MatchString(expression) ||
-			regexp.MustCompile(`y`).MatchString(expression) ||
-			regexp.MustCompile(`theta`).MatchString(expression) {
-			panic("Insufficient coordinate names given, the following coordinate names are allowed together: (x,y,z) for cartesian coordinates, (r,phi,z) for cylinder coordinates and (r,theta,phi) for spherical coordinates")
-		}
-	case "sph":
-		if regexp.MustCompile(`x[^p]`).MatchString(expression) ||
-			regexp.MustCompile(`y`).MatchString(expression) ||
-			regexp.MustCompile(`z`).MatchString(expression) {
-			panic("Insufficient coordinate names given, the following coordinate names are allowed together: (x,y,z) for cartesian coordinates, (r,phi,z) for cylinder coordinates and (r,theta,phi) for spherical coordinates")
-		}
-	default:
-		panic("Insufficient coordinate names given, the following coordinate names are allowed together: (x,y,z) for cartesian coordinates, (r,phi,z) for cylinder coordinates and (r,theta,phi) for spherical coordinates")
-	}
+// Returns a copy of scalarField where the parameter name has the value value
+func (s scalarField) Bind(name string, value float64) scalarField {
+	return s.BindParams(map[string]float64{name: value})
+}
 
+// Returns a copy of scalarField where each parameter in values has its value
+func (s scalarField) BindParams(values map[string]float64) scalarField {
+	s.opts = s.opts.bind(values)
+	return s
 }
 
-// Returns the calculation of the expression given the points _1, _2, _3 in coordinate system
-// Effectivley this function sorts out the addition and subtraction of the terms in the expression
-func fn(_1, _2, _3 float64, expression string, coordsys string) float64 {
-	var res float64
+// Returns the boundaries where a comparison in the expression of scalarField switches, as equations like "r=1"
+// The field may be discontinuous there and its derivatives are only correct away from them
+func (s scalarField) Discontinuities() []string {
+	return s.opts.discontinuities(s.expression)
+}
 
-	// Split "+" and "-" and calculate sub parts containg "*" and "/"
-	partsToCombine := strings.FieldsFunc(expression, fnSplitHelper)
-	OPRList := listOPR(expression)
-	// firstPart := partsToCombine[0]
+// Returns a copy of vectorField where the parameter name has the value value
+func (v vectorField) Bind(name string, value float64) vectorField {
+	return v.BindParams(map[string]float64{name: value})
+}
 
-	for i, v := range partsToCombine {
-		// Get the sign of the other terms
-		switch OPRList[i] {
-		case "+":
-			res += calculateTerm(_1, _2, _3, v, coordsys)
-		case "-":
-			res -= calculateTerm(_1, _2, _3, v, coordsys)
-		default:
-			res += calculateTerm(_1, _2, _3, v, coordsys)
-		}
+// Returns a copy of vectorField where each parameter in values has its value
+func (v vectorField) BindParams(values map[string]float64) vectorField {
+	v.opts = v.opts.bind(values)
+	return v
+}
 
-	}
-	return res
+// Returns the boundaries where a comparison in an expression of vectorField switches, as equations like "r=1"
+// The field may be discontinuous there and its derivatives are only correct away from them
+func (v vectorField) Discontinuities() []string {
+	return v.opts.discontinuities(v.expressionCoord1, v.expressionCoord2, v.expressionCoord3)
+}
+
+// Returns the calculation of scalarField at the points _1, _2, _3 with its parameters bound
+func (s scalarField) fn(_1, _2, _3 float64) float64 {
+	return s.eval([]float64{_1, _2, _3})
+}
+
+// Returns the calculation of scalarField at point c with its parameters bound
+func (s scalarField) eval(c []float64) float64 {
+	c, coords := s.opts.withTime(c, coordNames(s.coordsys))
+	return s.opts.fnN(c, s.tree, coords)
+}
+
+// Returns the calculation of the tree of one coordinate of vectorField at the points _1, _2, _3 with its parameters bound
+func (v vectorField) fn(_1, _2, _3 float64, tree *node) float64 {
+	c, coords := v.opts.withTime([]float64{_1, _2, _3}, coordNames(v.coordsys))
+	return v.opts.fnN(c, tree, coords)
 }
 
-// Takes type rune and returns true if rune has - or + operator
-// Taken from https://stackoverflow.com/questions/39862613/how-to-split-multiple-delimiter-in-golang?utm_medium=organic&utm_source=google_rich_qa&utm_campaign=google_rich_qa
-func fnSplitHelper(r rune) bool {
-	return r == '-' || r == '+'
+// Returns vectorField with the expression of each component simplified like Simplify of scalarField
+func (v vectorField) Simplify() vectorField {
+	return v.withExpressions(v.treeCoord1.simplify().String(), v.treeCoord2.simplify().String(), v.treeCoord3.simplify().String())
 }
 
-// Returns list of the addition and subtraction operation in the order they appear
-func listOPR(expression string) []string {
-	var OPRList []string
+// Returns the components of vectorField as canonical text like (x, -y, 0)
+func (v vectorField) String() string {
+	return v.opts.vectorString(v.expressionCoord1, v.expressionCoord2, v.expressionCoord3)
+}
+
+// Returns vectorField as LaTeX, the sum of each component times the unit vector of its coordinate
+func (v vectorField) LaTeX() string {
+	return v.opts.vectorLatex(v.coordsys, v.expressionCoord1, v.expressionCoord2, v.expressionCoord3)
+}
+
+// Returns the calculation of each coordinate of vectorField at point c with its parameters bound
+func (v vectorField) eval(c []float64) []float64 {
+	return []float64{
+		v.fn(c[0], c[1], c[2], v.treeCoord1),
+		v.fn(c[0], c[1], c[2], v.treeCoord2),
+		v.fn(c[0], c[1], c[2], v.treeCoord3)}
+}
 
-	submatches := mathParser(expression)
+// Panic message used by checkCoords when the coordinate names do not match the coordinate system
+const coordsErr = "Insufficient coordinate names given, the following coordinate names are allowed together: (x,y,z) for cartesian coordinates, (r,phi,z) for cylinder coordinates, (r,theta,phi) for spherical coordinates, (x,y) for 2D cartesian coordinates and (r,phi) for polar coordinates"
 
-	// First element is empety if operator is missing in the beginning
-	if submatches[0][1] == "+" || submatches[0][1] == "-" {
-		OPRList = append(OPRList, submatches[0][1])
-	} else {
-		OPRList = append(OPRList, "")
+// Checks if user has used right coordinate names, declared parameter names, known functions and valid syntax, panics if not
+func checkCoords(expression string, coordsys string, o fieldOptions) {
+	tree := o.mustParse(expression)
+	checkParams(o)
+	if coordNames(coordsys) == nil {
+		panic(coordsErr)
+	}
+	declared := map[string]bool{}
+	for _, name := range o.params {
+		declared[name] = true
 	}
-	for i, match := range submatches {
-		if i > 0 && (match[1] == "+" || match[1] == "-") {
-			OPRList = append(OPRList, match[1])
+	for _, name := range tree.variables() {
+		switch {
+		case declared[name] || isConst(name) || isCoord(name, coordsys) || name == timeName:
+		case o.part != notComplex && name == imagName:
+		case isCoord(name, "car") || isCoord(name, "cyl") || isCoord(name, "sph"):
+			panic(coordsErr)
+		default:
+			panic("Unknown name " + name + " in expression, it is neither a coordinate of " + coordsys + " nor a parameter declared with WithParams")
 		}
 	}
-	return OPRList
-}
-
-// Returns a calucaltion of the given term in expression at the points _1, _2, _3 given the coordinate system coordsys
-// This function effectively manages the calculation of multiplication and division in each term in expression
-// It's the used in fn which preforms the addition and subtraction
-func calculateTerm(_1, _2, _3 float64, expression string, coordsys string) float64 {
-	// var res float64
-	var arg float64 = 1
-	var term float64 = 1
-	// Regular expressions
-	var OPR string
-	var COEFFUNC string
-	var FUNC string
-	var EXPFUNC string
-	var COEFCOORD string
-	var COORD string
-	var EXPCOORD string
-
-	if expression == "" {
-		term = 0
-	} else {
-		submatches := mathParser(expression)
-
-		for i, match := range submatches {
-			OPR = match[1]
-			COEFFUNC = match[2]
-			FUNC = match[3]
-			COEFCOORD = match[4]
-			COORD = match[5]
-			EXPCOORD = match[6]
-			EXPFUNC = match[7]
-
-			arg = getCOEF(COEFCOORD) * math.Pow(getCOORD(_1, _2, _3, COORD, coordsys), getCOEF(EXPCOORD))
-
-			if i == 0 && (OPR == "*" || OPR == "/") {
-				term = 1
-			} else if OPR == "+" || OPR == "-" {
-				panic("Operator + or - not allowed in this function")
-			}
+}
+
+// Matches a valid name of a parameter or variable, that is letters optionally followed by digits
+var identifier = regexp.MustCompile(`^[a-zA-Z]+[0-9]*$`)
 
-			// Calculate the term given the operator infront of it
-			switch OPR {
-			case "":
-				term = getCOEF(COEFFUNC) * math.Pow(getFUNC(FUNC, arg), getCOEF(EXPFUNC))
-			case "*":
-				term *= getCOEF(COEFFUNC) * math.Pow(getFUNC(FUNC, arg), getCOEF(EXPFUNC))
-			case "/":
-				term /= getCOEF(COEFFUNC) * math.Pow(getFUNC(FUNC, arg), getCOEF(EXPFUNC))
+// Checks that the parameter names of o are valid and can not be mistaken for coordinates or functions, panics if not
+func checkParams(o fieldOptions) {
+	declared := map[string]bool{}
+	for _, name := range o.params {
+		if !identifier.MatchString(name) {
+			panic("Parameter name " + name + " is invalid, names are letters optionally followed by digits")
+		}
+		if o.funcs.isFUNC(name) {
+			panic("Parameter name " + name + " is the name of a function")
+		}
+		if isConst(name) {
+			panic("Parameter name " + name + " is the name of a constant")
+		}
+		for _, coord := range defaultCoords {
+			if name == coord {
+				panic("Parameter name " + name + " is the name of a coordinate")
 			}
 		}
+		if name == timeName {
+			panic("Parameter name " + name + " is the name of the time")
+		}
+		if o.part != notComplex && name == imagName {
+			panic("Parameter name " + name + " is the imaginary unit of a complex field")
+		}
+		if declared[name] {
+			panic("Parameter name " + name + " is declared more than once")
+		}
+		declared[name] = true
 	}
-	return term
 }
 
-// Returns a list of strings with submatches of the parsed mathematical expression
-func mathParser(expression string) [][]string {
-	OPRERATIONS := `\+|\-|\*|\/`
-	FUNCTIONS := `sin|cos|exp|sqrt|tan`
-	COORDINATES := `x|y|z|r|phi|theta`
-	REGEXP := `\s?(?P<OPR>` + OPRERATIONS + `)?\s?(?:(?P<COEFFUNC>\d+)?(?P<FUNC>` + FUNCTIONS + `))?\(?(?P<COEFCOORD>\d+)?(?P<COORD>` + COORDINATES + `)?\^?(?P<EXPCOORD>\d+)?\)?\^?(?P<EXPFUNC>\d+)?`
-	re := regexp.MustCompile(REGEXP)
-	submatches := re.FindAllStringSubmatch(expression, -1)
-	return submatches
+// Returns true if name is the name of a coordinate in coordsys
+func isCoord(name string, coordsys string) bool {
+	for _, coord := range coordNames(coordsys) {
+		if name == coord {
+			return true
+		}
+	}
+	return false
 }
 
-// Takes a string containing a coefficient
-// If string is empty it returns 1, thus the coefficient has no effect in expression
-// else convert to float64 and return it
-func getCOEF(COEF string) float64 {
-	if COEF == "" {
-		return 1
-	} else {
-		COEFF, _ := strconv.ParseFloat(COEF, 64)
-		return COEFF
+// Returns the calculation of the tree of an expression at the point c, where c[i] is the value of the coordinate
+// coords[i], with the settings o
+// A complex field is calculated as its real or imaginary part, chosen by o.part
+func (o fieldOptions) fnN(c []float64, tree *node, coords []string) float64 {
+	switch o.part {
+	case realPart:
+		return real(tree.evalComplex(c, coords))
+	case imagPart:
+		return imag(tree.evalComplex(c, coords))
+	default:
+		return tree.eval(c, coords)
 	}
 }
 
-// Takes a the coordinates seperatly as float64, the cordinate and coordinatesystem as strings
-// If COORD is empty it returns 1, thus the cordinate has no effect in expression
-// else check which coordinate it is given coordsys and return the given value of the coordinate
-func getCOORD(_1, _2, _3 float64, COORD string, coordsys string) float64 {
-	var COORD1 string
-	var COORD2 string
-	var COORD3 string
+// The coordinate names of all three-dimensional coordinate systems
+var defaultCoords = []string{"x", "y", "z", "r", "phi", "theta"}
 
-	switch coordsys { // Get the right name of coordinates given coordinate system
+// Returns the names of the coordinates in coordsys in the order they are given in a point
+func coordNames(coordsys string) []string {
+	switch coordsys {
 	case "car":
-		COORD1 = "x"
-		COORD2 = "y"
-		COORD3 = "z"
-
+		return []string{"x", "y", "z"}
 	case "cyl":
-		COORD1 = "r"
-		COORD2 = "phi"
-		COORD3 = "z"
-
+		return []string{"r", "phi", "z"}
 	case "sph":
-		COORD1 = "r"
-		COORD2 = "theta"
-		COORD3 = "phi"
+		return []string{"r", "theta", "phi"}
+	case "car2":
+		return []string{"x", "y"}
+	case "polar":
+		return []string{"r", "phi"}
+	default:
+		return nil
 	}
+}
 
-	switch COORD {
-	case COORD1: // Use _1 as coordinate value
-		return _1
-	case COORD2: // Use _2 as coordinate value
-		return _2
-	case COORD3: // Use _3 as coordinate value
-		return _3
-	default:
+// Takes the point c and the coordinate names coords, where c[i] is the value of coords[i]
+// If COORD is empty or unknown it returns 1, thus the cordinate has no effect in expression
+// else return the value of the coordinate named COORD
+func getCOORDN(c []float64, COORD string, coords []string) float64 {
+	if COORD == "" {
 		return 1
 	}
+	for i, name := range coords {
+		if name == COORD && i < len(c) {
+			return c[i]
+		}
+	}
+	return 1
 }
 
 // Takes a mathematical function as string and its arguments as float64
 // Returns calculated value of the actual function given the arguments
-// The functions are defined in expressionParser as FUNCTIONS
-func getFUNC(FUNC string, arg float64) float64 {
-	switch FUNC {
-	case "sin":
-		return math.Sin(arg)
-	case "cos":
-		return math.Cos(arg)
-	case "exp":
-		return math.Exp(arg)
-	case "sqrt":
-		return math.Sqrt(arg)
-	case "tan":
-		return math.Tan(arg)
-	case "":
-		return arg
-	default:
+// The functions are defined in functions, an empty FUNC returns its only argument
+func getFUNC(FUNC string, args ...float64) float64 {
+	if FUNC == "" {
+		return args[0]
+	}
+	f, ok := functions[FUNC]
+	if !ok {
 		panic("Error finding function")
 	}
+	return f.eval(args...)
 }
 
 // Calculates the gradient of scalarField
 // Returns a slice of float64 containg the calculated gradient at point c
 func (s scalarField) Grad(c []float64) []float64 {
+	grad, err := s.TryGrad(c)
+	if err != nil {
+		panic(singularMsg(s.coordsys))
+	}
+	return grad
+}
+
+// Calculates the gradient of scalarField like Grad
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (s scalarField) TryGrad(c []float64) ([]float64, error) {
 	if len(c) < 3 || len(c) > 3 {
 		panic("Too many or too few points coordinates given")
 	}
+	return atSingularity(c, s.coordsys, s.opts.singularity, s.grad, func(c []float64) []float64 {
+		return gradCartesian(s.eval, c, s.coordsys)
+	})
+}
+
+// Returns the gradient of scalarField at point c, which is not a singular point
+func (s scalarField) grad(c []float64) []float64 {
 	h := 0.0001
 	switch s.coordsys {
 	case "car":
@@ -272,46 +327,130 @@ func (s scalarField) Grad(c []float64) []float64 {
 		z := c[2]
 
 		return []float64{
-			(fn(x+h, y, z, s.expression, s.coordsys) - fn(x-h, y, z, s.expression, s.coordsys)) / (2 * h),
-			(fn(x, y+h, z, s.expression, s.coordsys) - fn(x, y-h, z, s.expression, s.coordsys)) / (2 * h),
-			(fn(x, y, z+h, s.expression, s.coordsys) - fn(x, y, z-h, s.expression, s.coordsys)) / (2 * h)}
+			(s.fn(x+h, y, z) - s.fn(x-h, y, z)) / (2 * h),
+			(s.fn(x, y+h, z) - s.fn(x, y-h, z)) / (2 * h),
+			(s.fn(x, y, z+h) - s.fn(x, y, z-h)) / (2 * h)}
 
 	case "cyl":
 		r := c[0]
 		phi := c[1]
 		z := c[2]
-		if r == 0 {
-			panic("r must be larger than zero")
-		} else {
-			return []float64{
-				(fn(r+h, phi, z, s.expression, s.coordsys) - fn(r-h, phi, z, s.expression, s.coordsys)) / (2 * h),
-				(fn(r, phi+h, z, s.expression, s.coordsys) - fn(r, phi-h, z, s.expression, s.coordsys)) / (2 * h * r),
-				(fn(r, phi, z+h, s.expression, s.coordsys) - fn(r, phi, z-h, s.expression, s.coordsys)) / (2 * h)}
-		}
+		return []float64{
+			(s.fn(r+h, phi, z) - s.fn(r-h, phi, z)) / (2 * h),
+			(s.fn(r, phi+h, z) - s.fn(r, phi-h, z)) / (2 * h * r),
+			(s.fn(r, phi, z+h) - s.fn(r, phi, z-h)) / (2 * h)}
 	case "sph":
 		r := c[0]
 		theta := c[1]
 		phi := c[2]
-		if r == 0 || math.Sin(theta) == 0 {
-			panic("r must be larger than zero and theta cannot be 0 or pi")
-		} else {
-			return []float64{
-				(fn(r+h, theta, phi, s.expression, s.coordsys) - fn(r-h, theta, phi, s.expression, s.coordsys)) / (2 * h),
-				(fn(r, theta+h, phi, s.expression, s.coordsys) - fn(r, theta-h, phi, s.expression, s.coordsys)) / (2 * h * r),
-				(fn(r, theta, phi+h, s.expression, s.coordsys) - fn(r, theta, phi-h, s.expression, s.coordsys)) / (2 * h * r * math.Sin(theta))}
-		}
+		return []float64{
+			(s.fn(r+h, theta, phi) - s.fn(r-h, theta, phi)) / (2 * h),
+			(s.fn(r, theta+h, phi) - s.fn(r, theta-h, phi)) / (2 * h * r),
+			(s.fn(r, theta, phi+h) - s.fn(r, theta, phi-h)) / (2 * h * r * math.Sin(theta))}
 	default:
 		panic("Error finding Grad, coordinates system is wrong")
 	}
 
 }
 
+// Calculates the laplacian of scalarField
+// Returns a float64 containg the calculated laplacian at point c
+func (s scalarField) Laplacian(c []float64) float64 {
+	res, err := s.TryLaplacian(c)
+	if err != nil {
+		panic(singularMsg(s.coordsys))
+	}
+	return res
+}
+
+// Calculates the laplacian of scalarField like Laplacian
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (s scalarField) TryLaplacian(c []float64) (float64, error) {
+	if len(c) != 3 {
+		panic("Too many or too few points coordinates given")
+	}
+	res, err := atSingularity(c, s.coordsys, s.opts.singularity, func(c []float64) []float64 {
+		return []float64{s.laplacian(c)}
+	}, func(c []float64) []float64 {
+		return []float64{laplacianCartesian(s.eval, c, s.coordsys)}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res[0], nil
+}
+
+// Returns the laplacian of scalarField at point c, which is not a singular point
+func (s scalarField) laplacian(c []float64) float64 {
+	// Second derivatives lose precision to rounding with a step as small as in Grad
+	h := 0.001
+	switch s.coordsys {
+	case "car":
+		x := c[0]
+		y := c[1]
+		z := c[2]
+		f := s.fn(x, y, z)
+
+		return (s.fn(x+h, y, z)-2*f+s.fn(x-h, y, z))/(h*h) +
+			(s.fn(x, y+h, z)-2*f+s.fn(x, y-h, z))/(h*h) +
+			(s.fn(x, y, z+h)-2*f+s.fn(x, y, z-h))/(h*h)
+
+	case "cyl":
+		r := c[0]
+		phi := c[1]
+		z := c[2]
+		f := s.fn(r, phi, z)
+
+		return (s.fn(r+h, phi, z)-2*f+s.fn(r-h, phi, z))/(h*h) +
+			(s.fn(r+h, phi, z)-s.fn(r-h, phi, z))/(2*h*r) +
+			(s.fn(r, phi+h, z)-2*f+s.fn(r, phi-h, z))/(h*h*r*r) +
+			(s.fn(r, phi, z+h)-2*f+s.fn(r, phi, z-h))/(h*h)
+	case "sph":
+		r := c[0]
+		theta := c[1]
+		phi := c[2]
+		f := s.fn(r, theta, phi)
+		sin := math.Sin(theta)
+
+		return (s.fn(r+h, theta, phi)-2*f+s.fn(r-h, theta, phi))/(h*h) +
+			(s.fn(r+h, theta, phi)-s.fn(r-h, theta, phi))/(h*r) +
+			(s.fn(r, theta+h, phi)-2*f+s.fn(r, theta-h, phi))/(h*h*r*r) +
+			(s.fn(r, theta+h, phi)-s.fn(r, theta-h, phi))/(2*h*r*r*math.Tan(theta)) +
+			(s.fn(r, theta, phi+h)-2*f+s.fn(r, theta, phi-h))/(h*h*r*r*sin*sin)
+	default:
+		panic("Error finding Laplacian, coordinates system is wrong")
+	}
+}
+
 // Calculates the divergence of vectorField
 // Returns a float64 containg the calculated divergence at point c
 func (v vectorField) Div(c []float64) float64 {
+	div, err := v.TryDiv(c)
+	if err != nil {
+		panic(singularMsg(v.coordsys))
+	}
+	return div
+}
+
+// Calculates the divergence of vectorField like Div
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (v vectorField) TryDiv(c []float64) (float64, error) {
 	if len(c) < 3 || len(c) > 3 {
 		panic("Too many or too few points coordinates given")
 	}
+	div, err := atSingularity(c, v.coordsys, v.opts.singularity, func(c []float64) []float64 {
+		return []float64{v.div(c)}
+	}, func(c []float64) []float64 {
+		return []float64{divCartesian(v.eval, c, v.coordsys)}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return div[0], nil
+}
+
+// Returns the divergence of vectorField at point c, which is not a singular point
+func (v vectorField) div(c []float64) float64 {
 	h := 0.0001
 	switch v.coordsys {
 	case "car":
@@ -319,35 +458,27 @@ func (v vectorField) Div(c []float64) float64 {
 		y := c[1]
 		z := c[2]
 
-		return ((fn(x+h, y, z, v.expressionCoord1, v.coordsys)-fn(x-h, y, z, v.expressionCoord1, v.coordsys))/(2*h) +
-			(fn(x, y+h, z, v.expressionCoord2, v.coordsys)-fn(x, y-h, z, v.expressionCoord2, v.coordsys))/(2*h) +
-			(fn(x, y, z+h, v.expressionCoord3, v.coordsys)-fn(x, y, z-h, v.expressionCoord3, v.coordsys))/(2*h))
+		return ((v.fn(x+h, y, z, v.treeCoord1)-v.fn(x-h, y, z, v.treeCoord1))/(2*h) +
+			(v.fn(x, y+h, z, v.treeCoord2)-v.fn(x, y-h, z, v.treeCoord2))/(2*h) +
+			(v.fn(x, y, z+h, v.treeCoord3)-v.fn(x, y, z-h, v.treeCoord3))/(2*h))
 
 	case "cyl":
 		r := c[0]
 		phi := c[1]
 		z := c[2]
-		if r == 0 {
-			panic("r must be larger than zero")
-		} else {
-			return (fn(r, phi, z, v.expressionCoord1, v.coordsys)/r +
-				(fn(r+h, phi, z, v.expressionCoord1, v.coordsys)-fn(r-h, phi, z, v.expressionCoord1, v.coordsys))/(2*h) +
-				(fn(r, phi+h, z, v.expressionCoord2, v.coordsys)-fn(r, phi-h, z, v.expressionCoord2, v.coordsys))/(2*h*r) +
-				(fn(r, phi, z+h, v.expressionCoord3, v.coordsys)-fn(r, phi, z-h, v.expressionCoord3, v.coordsys))/(2*h))
-		}
+		return (v.fn(r, phi, z, v.treeCoord1)/r +
+			(v.fn(r+h, phi, z, v.treeCoord1)-v.fn(r-h, phi, z, v.treeCoord1))/(2*h) +
+			(v.fn(r, phi+h, z, v.treeCoord2)-v.fn(r, phi-h, z, v.treeCoord2))/(2*h*r) +
+			(v.fn(r, phi, z+h, v.treeCoord3)-v.fn(r, phi, z-h, v.treeCoord3))/(2*h))
 	case "sph":
 		r := c[0]
 		theta := c[1]
 		phi := c[2]
-		if r == 0 || math.Sin(theta) == 0 {
-			panic("r must be larger than zero and theta cannot be 0 or pi")
-		} else {
-			return ((2*fn(r, theta, phi, v.expressionCoord1, v.coordsys))/r +
-				fn(r, theta, phi, v.expressionCoord2, v.coordsys)/(r*math.Tan(theta)) +
-				(fn(r+h, theta, phi, v.expressionCoord1, v.coordsys)-fn(r-h, theta, phi, v.expressionCoord1, v.coordsys))/(2*h) +
-				(fn(r, theta+h, phi, v.expressionCoord2, v.coordsys)-fn(r, theta-h, phi, v.expressionCoord2, v.coordsys))/(2*h*r) +
-				(fn(r, theta, phi+h, v.expressionCoord3, v.coordsys)-fn(r, theta, phi-h, v.expressionCoord3, v.coordsys))/(2*h*r*math.Sin(theta)))
-		}
+		return ((2*v.fn(r, theta, phi, v.treeCoord1))/r +
+			v.fn(r, theta, phi, v.treeCoord2)/(r*math.Tan(theta)) +
+			(v.fn(r+h, theta, phi, v.treeCoord1)-v.fn(r-h, theta, phi, v.treeCoord1))/(2*h) +
+			(v.fn(r, theta+h, phi, v.treeCoord2)-v.fn(r, theta-h, phi, v.treeCoord2))/(2*h*r) +
+			(v.fn(r, theta, phi+h, v.treeCoord3)-v.fn(r, theta, phi-h, v.treeCoord3))/(2*h*r*math.Sin(theta)))
 	default:
 		panic("Error finding Div, coordinates system is wrong")
 	}
@@ -356,9 +487,26 @@ func (v vectorField) Div(c []float64) float64 {
 // Calculates the rotation of vectorField
 // Returns a float64 containg the calculated rotation at point c
 func (v vectorField) Rot(c []float64) []float64 {
+	rot, err := v.TryRot(c)
+	if err != nil {
+		panic(singularMsg(v.coordsys))
+	}
+	return rot
+}
+
+// Calculates the rotation of vectorField like Rot
+// Returns ErrSingularPoint instead of panicking when c is a singular point and the field uses SingularityReject
+func (v vectorField) TryRot(c []float64) ([]float64, error) {
 	if len(c) < 3 || len(c) > 3 {
 		panic("Too many or too few points coordinates given")
 	}
+	return atSingularity(c, v.coordsys, v.opts.singularity, v.rot, func(c []float64) []float64 {
+		return rotCartesian(v.eval, c, v.coordsys)
+	})
+}
+
+// Returns the rotation of vectorField at point c, which is not a singular point
+func (v vectorField) rot(c []float64) []float64 {
 	h := 0.0001
 	switch v.coordsys {
 	case "car":
@@ -367,47 +515,39 @@ func (v vectorField) Rot(c []float64) []float64 {
 		z := c[2]
 
 		return []float64{
-			(fn(x, y+h, z, v.expressionCoord3, v.coordsys)-fn(x, y-h, z, v.expressionCoord3, v.coordsys))/(2*h) -
-				(fn(x, y, z+h, v.expressionCoord2, v.coordsys)-fn(x, y, z-h, v.expressionCoord2, v.coordsys))/(2*h),
-			(fn(x, y, z+h, v.expressionCoord1, v.coordsys)-fn(x, y, z-h, v.expressionCoord1, v.coordsys))/(2*h) -
-				(fn(x+h, y, z, v.expressionCoord3, v.coordsys)-fn(x-h, y, z, v.expressionCoord3, v.coordsys))/(2*h),
-			(fn(x+h, y, z, v.expressionCoord2, v.coordsys)-fn(x-h, y, z, v.expressionCoord2, v.coordsys))/(2*h) -
-				(fn(x, y+h, z, v.expressionCoord1, v.coordsys)-fn(x, y-h, z, v.expressionCoord1, v.coordsys))/(2*h)}
+			(v.fn(x, y+h, z, v.treeCoord3)-v.fn(x, y-h, z, v.treeCoord3))/(2*h) -
+				(v.fn(x, y, z+h, v.treeCoord2)-v.fn(x, y, z-h, v.treeCoord2))/(2*h),
+			(v.fn(x, y, z+h, v.treeCoord1)-v.fn(x, y, z-h, v.treeCoord1))/(2*h) -
+				(v.fn(x+h, y, z, v.treeCoord3)-v.fn(x-h, y, z, v.treeCoord3))/(2*h),
+			(v.fn(x+h, y, z, v.treeCoord2)-v.fn(x-h, y, z, v.treeCoord2))/(2*h) -
+				(v.fn(x, y+h, z, v.treeCoord1)-v.fn(x, y-h, z, v.treeCoord1))/(2*h)}
 
 	case "cyl":
 		r := c[0]
 		phi := c[1]
 		z := c[2]
-		if r == 0 {
-			panic("r must be larger than zero")
-		} else {
-			return []float64{
-				(fn(r, phi+h, z, v.expressionCoord3, v.coordsys)-fn(r, phi-h, z, v.expressionCoord3, v.coordsys))/(2*h*r) -
-					(fn(r, phi, z+h, v.expressionCoord2, v.coordsys)-fn(r, phi, z-h, v.expressionCoord2, v.coordsys))/(2*h),
-				(fn(r, phi, z+h, v.expressionCoord1, v.coordsys)-fn(r, phi, z-h, v.expressionCoord1, v.coordsys))/(2*h) -
-					(fn(r+h, phi, z, v.expressionCoord3, v.coordsys)-fn(r-h, phi, z, v.expressionCoord3, v.coordsys))/(2*h),
-				fn(r, phi, z, v.expressionCoord2, v.coordsys)/r +
-					(fn(r+h, phi, z, v.expressionCoord2, v.coordsys)-fn(r-h, phi, z, v.expressionCoord2, v.coordsys))/(2*h) -
-					(fn(r, phi+h, z, v.expressionCoord1, v.coordsys)-fn(r, phi-h, z, v.expressionCoord1, v.coordsys))/(2*h*r)}
-		}
+		return []float64{
+			(v.fn(r, phi+h, z, v.treeCoord3)-v.fn(r, phi-h, z, v.treeCoord3))/(2*h*r) -
+				(v.fn(r, phi, z+h, v.treeCoord2)-v.fn(r, phi, z-h, v.treeCoord2))/(2*h),
+			(v.fn(r, phi, z+h, v.treeCoord1)-v.fn(r, phi, z-h, v.treeCoord1))/(2*h) -
+				(v.fn(r+h, phi, z, v.treeCoord3)-v.fn(r-h, phi, z, v.treeCoord3))/(2*h),
+			v.fn(r, phi, z, v.treeCoord2)/r +
+				(v.fn(r+h, phi, z, v.treeCoord2)-v.fn(r-h, phi, z, v.treeCoord2))/(2*h) -
+				(v.fn(r, phi+h, z, v.treeCoord1)-v.fn(r, phi-h, z, v.treeCoord1))/(2*h*r)}
 	case "sph":
 		r := c[0]
 		theta := c[1]
 		phi := c[2]
-		if r == 0 || math.Sin(theta) == 0 {
-			panic("r must be larger than zero and theta cannot be 0 or pi")
-		} else {
-			return []float64{
-				fn(r, theta, phi, v.expressionCoord2, v.coordsys)/(r*math.Tan(theta)) +
-					(fn(r, theta+h, phi, v.expressionCoord2, v.coordsys)-fn(r, theta-h, phi, v.expressionCoord2, v.coordsys))/(2*h*r) -
-					(fn(r, theta, phi+h, v.expressionCoord2, v.coordsys)-fn(r, theta, phi-h, v.expressionCoord2, v.coordsys))/(2*h*r*math.Sin(theta)),
-				(fn(r, theta, phi+h, v.expressionCoord2, v.coordsys)-fn(r, theta, phi-h, v.expressionCoord1, v.coordsys))/(2*h*r*math.Sin(theta)) -
-					fn(r, theta, phi, v.expressionCoord3, v.coordsys)/r -
-					(fn(r+h, theta, phi, v.expressionCoord3, v.coordsys)-fn(r-h, theta, phi, v.expressionCoord3, v.coordsys))/(2*h),
-				fn(r, theta, phi, v.expressionCoord2, v.coordsys)/r +
-					(fn(r+h, theta, phi, v.expressionCoord2, v.coordsys)-fn(r-h, theta, phi, v.expressionCoord2, v.coordsys))/(2*h) -
-					(fn(r, theta+h, phi, v.expressionCoord1, v.coordsys)-fn(r, theta-h, phi, v.expressionCoord1, v.coordsys))/(2*h*r)}
-		}
+		return []float64{
+			v.fn(r, theta, phi, v.treeCoord3)/(r*math.Tan(theta)) +
+				(v.fn(r, theta+h, phi, v.treeCoord3)-v.fn(r, theta-h, phi, v.treeCoord3))/(2*h*r) -
+				(v.fn(r, theta, phi+h, v.treeCoord2)-v.fn(r, theta, phi-h, v.treeCoord2))/(2*h*r*math.Sin(theta)),
+			(v.fn(r, theta, phi+h, v.treeCoord1)-v.fn(r, theta, phi-h, v.treeCoord1))/(2*h*r*math.Sin(theta)) -
+				v.fn(r, theta, phi, v.treeCoord3)/r -
+				(v.fn(r+h, theta, phi, v.treeCoord3)-v.fn(r-h, theta, phi, v.treeCoord3))/(2*h),
+			v.fn(r, theta, phi, v.treeCoord2)/r +
+				(v.fn(r+h, theta, phi, v.treeCoord2)-v.fn(r-h, theta, phi, v.treeCoord2))/(2*h) -
+				(v.fn(r, theta+h, phi, v.treeCoord1)-v.fn(r, theta-h, phi, v.treeCoord1))/(2*h*r)}
 	default:
 		panic("Error finding Rot, coordinates system is wrong")
 	}
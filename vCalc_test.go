@@ -6,6 +6,19 @@ import (
 	"testing"
 )
 
+// Returns true if a and b are equal, where NaN is equal to NaN
+func sameFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !(math.IsNaN(a[i]) && math.IsNaN(b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
 func TestNewScalarField(t *testing.T) {
 	var tests = []struct {
 		expression string
@@ -46,107 +59,22 @@ func TestFn(t *testing.T) {
 		exp        float64
 	}{
 		{[]float64{3, 3, 1}, "4x*y-3+z", "car", 34},
-		{[]float64{2, 4, 0}, "r^3-cos(phi)/theta", "sph", 7.75},
-		{[]float64{2, 4, 0}, "", "car", 0},
-		{[]float64{2, 4, 0}, "", "cyl", 0},
-		{[]float64{2, 4, 0}, "", "sph", 0},
-		{[]float64{0, 0, 0}, "r+phi+z", "cyl", 0},
-	}
-	for _, v := range tests {
-		if exp := fn(v.point[0], v.point[1], v.point[2], v.expression, v.coordsys); exp != v.exp {
-			t.Error("Test failed: {", v.point, v.expression, v.coordsys, " } inputted, expected {", v.exp, "} and got {", exp, "}")
-		}
-	}
-}
-
-func TestCalculateTerm(t *testing.T) {
-	var tests = []struct {
-		point      []float64
-		expression string
-		coordsys   string
-		exp        float64
-	}{
 		{[]float64{3, 3, 1}, "4x*y*z", "car", 36},
+		{[]float64{2, 4, 0}, "r^3-cos(phi)/theta", "sph", 7.75},
 		{[]float64{2, 4, 0}, "r^3*cos(phi)/theta", "sph", 2},
 		{[]float64{2, 4, 0}, "", "car", 0},
 		{[]float64{2, 4, 0}, "", "cyl", 0},
 		{[]float64{2, 4, 0}, "", "sph", 0},
+		{[]float64{0, 0, 0}, "r+phi+z", "cyl", 0},
 		{[]float64{0, 1, 0}, "r/phi*z", "cyl", 0},
 	}
 	for _, v := range tests {
-		if exp := calculateTerm(v.point[0], v.point[1], v.point[2], v.expression, v.coordsys); exp != v.exp {
+		if exp := mustParse(v.expression).eval(v.point, coordNames(v.coordsys)); exp != v.exp {
 			t.Error("Test failed: {", v.point, v.expression, v.coordsys, " } inputted, expected {", v.exp, "} and got {", exp, "}")
 		}
 	}
 }
 
-func TestListOPR(t *testing.T) {
-	var tests = []struct {
-		expression string
-		exp        []string
-	}{
-		{"", []string{""}},
-		{"*+/-", []string{"", "+", "-"}},
-		{"+", []string{"+"}},
-		{"3+35-43/54+cos(3x^3)^3", []string{"", "+", "-", "+"}},
-		{"+++--**//+", []string{"+", "+", "+", "-", "-", "+"}},
-		{"-3cos(x)-65y+theta/phi", []string{"-", "-", "+"}},
-	}
-	for _, v := range tests {
-		if exp := listOPR(v.expression); reflect.DeepEqual(exp, v.exp) == false {
-			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, "}")
-		}
-	}
-}
-
-func TestMathParser(t *testing.T) {
-	var tests = []struct {
-		expression string
-		exp        [][]string
-	}{
-		{"", [][]string{[]string{"", "", "", "", "", "", "", ""}}},
-		{"-3sin(2r^3)^5+phi*theta^2",
-			[][]string{
-				[]string{"-3sin(2r^3)^5", "-", "3", "sin", "2", "r", "3", "5"},
-				[]string{"+phi", "+", "", "", "", "phi", "", ""},
-				[]string{"*theta^2", "*", "", "", "", "theta", "2", ""},
-			},
-		},
-		{"72+3x^2+5cos(y^2)-3z",
-			[][]string{
-				[]string{"72", "", "", "", "72", "", "", ""},
-				[]string{"+3x^2", "+", "", "", "3", "x", "2", ""},
-				[]string{"+5cos(y^2)", "+", "5", "cos", "", "y", "2", ""},
-				[]string{"-3z", "-", "", "", "3", "z", "", ""},
-			},
-		},
-	}
-
-	for _, v := range tests {
-		if exp := mathParser(v.expression); reflect.DeepEqual(exp, v.exp) == false {
-			t.Error("Test failed: {", v.expression, " } inputted, expected {", v.exp, "} and got {", exp, "}")
-		}
-	}
-}
-
-func TestGetCOEF(t *testing.T) {
-	var tests = []struct {
-		in  string
-		exp float64
-	}{
-		{"", 1},
-		{"1", 1},
-		{"-4", -4},
-		{"3.43", 3.43},
-		{"0", 0},
-	}
-	for _, v := range tests {
-		if exp := getCOEF(v.in); exp != v.exp {
-			t.Error("Test failed: {", v.in, "} inputted, expected {", v.exp, "} and got {", exp, "}")
-		}
-	}
-}
-
 func TestGetCoord(t *testing.T) {
 	var tests = []struct {
 		_1, _2, _3 float64
@@ -165,7 +93,7 @@ func TestGetCoord(t *testing.T) {
 		{3, -0.4, 74, "phi", "sph", 74},
 	}
 	for _, v := range tests {
-		if exp := getCOORD(v._1, v._2, v._3, v.COORD, v.coordsys); exp != v.exp {
+		if exp := getCOORDN([]float64{v._1, v._2, v._3}, v.COORD, coordNames(v.coordsys)); exp != v.exp {
 			t.Error("Test failed: {", v._1, v._2, v._3, v.COORD+" "+v.coordsys+" } inputted, expected {", v.exp, "} and got {", exp, "}")
 		}
 	}
@@ -215,12 +143,12 @@ func TestDiv(t *testing.T) {
 		s     vectorField
 		exp   float64
 	}{
-		{[]float64{1, 3.14, math.Pi}, NewVectorField("3r^2", "5cos(theta^3*phi)", "sqrt(1-theta^2)-5phi+3", "sph"), -11774.075610130705},
-		{[]float64{-1, -1, -1}, NewVectorField("3r^2", "5cos(phi^3*z)", "sqrt(1-phi^2)-z+3", "cyl"), 2.622064867998322},
+		{[]float64{1, 3.14, math.Pi}, NewVectorField("3r^2", "5cos(theta^3*phi)", "sqrt(1-theta^2)-5phi+3", "sph"), math.NaN()},
+		{[]float64{-1, -1, -1}, NewVectorField("3r^2", "5cos(phi^3*z)", "sqrt(1-phi^2)-z+3", "cyl"), -22.6220648679879},
 		{[]float64{0, 0, 0}, NewVectorField("x^2+cos(7y)", "y^2", "3z^2", "car"), 0},
 	}
 	for _, v := range tests {
-		if exp := v.s.Div(v.point); exp != v.exp {
+		if exp := v.s.Div(v.point); !sameFloats([]float64{exp}, []float64{v.exp}) {
 			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
 		}
 	}
@@ -232,13 +160,84 @@ func TestRot(t *testing.T) {
 		s     vectorField
 		exp   []float64
 	}{
-		{[]float64{1, 3.14, math.Pi}, NewVectorField("3r^2", "5cos(theta^3*phi)", "sqrt(1-theta^2)-5phi+3", "sph"), []float64{-11464.210893801464, 3.484117402575223e+07, 14.097523877137295}},
-		{[]float64{-1, -1, -1}, NewVectorField("3r^2", "5cos(phi^3*z)", "sqrt(1-phi^2)-z+3", "cyl"), []float64{-4.701511529343616, 0, 2.701511529340699}},
+		{[]float64{1, 3.14, math.Pi}, NewVectorField("3r^2", "5cos(theta^3*phi)", "sqrt(1-theta^2)-5phi+3", "sph"), []float64{math.NaN(), math.NaN(), -4.9588703389071345}},
+		{[]float64{-1, -1, -1}, NewVectorField("3r^2", "5cos(phi^3*z)", "sqrt(1-phi^2)-z+3", "cyl"), []float64{math.NaN(), 0, -2.701511529340699}},
 		{[]float64{0, 0, 0}, NewVectorField("x^2+cos(7y)", "y^2", "3z^2", "car"), []float64{0, 0, 0}},
 	}
 	for _, v := range tests {
-		if exp := v.s.Rot(v.point); reflect.DeepEqual(exp, v.exp) == false {
+		if exp := v.s.Rot(v.point); !sameFloats(exp, v.exp) {
 			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
 		}
 	}
 }
+
+func TestScalarFieldDiff(t *testing.T) {
+	var tests = []struct {
+		s     scalarField
+		coord string
+		exp   string
+	}{
+		{NewScalarField("x^2*y", "car"), "x", "2*x*y"},
+		{NewScalarField("3r^2+z", "cyl"), "z", "1"},
+		{NewScalarField("q*cos(theta)", "sph", WithParams("q")), "q", "cos(theta)"},
+		{NewScalarField("if(r<1, r^3, 1/r)", "sph"), "r", "if(r<1, 3*r^2, -1/r^2)"},
+	}
+	for _, v := range tests {
+		if exp := v.s.Diff(v.coord); exp.expression != v.exp || exp.coordsys != v.s.coordsys {
+			t.Error("Test failed: {", v.s, v.coord, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestPiecewiseGrad(t *testing.T) {
+	// The potential of a uniformly charged sphere of radius 1
+	s := NewScalarField("if(r<1, (3-r^2)/2, 1/r)", "sph")
+	var tests = []struct {
+		point []float64
+		exp   []float64
+	}{
+		{[]float64{0.5, 1, 1}, []float64{-0.5, 0, 0}},
+		{[]float64{2, 1, 1}, []float64{-0.25, 0, 0}},
+	}
+	for _, v := range tests {
+		if exp := s.Grad(v.point); !almostEqual(exp, v.exp, 1e-6) {
+			t.Error("Test failed: {", v.point, s, " } inputted, expected {", v.exp, "} and got {", exp, "}")
+		}
+	}
+}
+
+func TestDiscontinuities(t *testing.T) {
+	var tests = []struct {
+		exp []string
+		got []string
+	}{
+		{[]string{"r=1", "z=0"}, NewScalarField("if(r<1, r^2, 1/r)+(z>=0)", "cyl").Discontinuities()},
+		{nil, NewScalarField("x^2", "car").Discontinuities()},
+		{[]string{"x=y"}, NewVectorField("piecewise(x<y, 1, x==y, 0, -1)", "0", "x>y", "car").Discontinuities()},
+		{[]string{"r=2"}, NewVectorField2D("if(r<2, r, 2)", "r>=2", "polar").Discontinuities()},
+		{[]string{"x1*x2=1"}, NewScalarFieldN("if(x1*x2>1, x1, x2)", []string{"x1", "x2"}).Discontinuities()},
+	}
+	for _, v := range tests {
+		if !reflect.DeepEqual(v.got, v.exp) {
+			t.Error("Test failed: expected {", v.exp, "} and got {", v.got, "}")
+		}
+	}
+}
+
+func TestLaplacian(t *testing.T) {
+	var tests = []struct {
+		point []float64
+		s     scalarField
+		exp   float64
+	}{
+		{[]float64{1, 2, 3}, NewScalarField("x^2+y^2*z", "car"), 2 + 2*3},
+		{[]float64{2, 1, 3}, NewScalarField("r^2*cos(phi)+z^3", "cyl"), 3*math.Cos(1) + 18},
+		{[]float64{2, 1, 3}, NewScalarField("r^2*sin(theta)*cos(phi)", "sph"), (6-1/math.Pow(math.Sin(1), 2))*math.Sin(1)*math.Cos(3) + math.Cos(2)/math.Sin(1)*math.Cos(3)},
+		{[]float64{1.5, 0.5, 1}, NewScalarField("1/r", "sph"), 0},
+	}
+	for _, v := range tests {
+		if got := v.s.Laplacian(v.point); math.Abs(got-v.exp) > 1e-4 {
+			t.Error("Test failed: {", v.point, v.s, " } inputted, expected {", v.exp, "} and got {", got, "}")
+		}
+	}
+}